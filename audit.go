@@ -0,0 +1,162 @@
+package confluent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+const (
+	auditConfigPath = "/security/1.0/audit/config"
+	auditRoutesPath = "/security/1.0/audit/routes"
+	auditLookupPath = "/security/1.0/audit/lookup"
+)
+
+// ErrAuditLogConfigConflict is returned by UpdateAuditLogConfig when the config was modified since it was read
+var ErrAuditLogConfigConflict = errors.New("audit log config was modified concurrently, get it again and retry")
+
+// AuditLogConfig is the cluster registry wide audit log routing configuration
+// @ref https://docs.confluent.io/platform/current/security/audit-logs/audit-logs-properties-config.html
+type AuditLogConfig struct {
+	Destinations       AuditLogDestinations               `json:"destinations"`
+	DefaultTopics      AuditLogTopics                     `json:"default_topics"`
+	ExcludedPrincipals []string                           `json:"excluded_principals,omitempty"`
+	Routes             map[string]AuditLogRouteCategories `json:"routes,omitempty"`
+	Metadata           *AuditLogConfigMetadata            `json:"metadata,omitempty"`
+}
+
+type AuditLogDestinations struct {
+	BootstrapServers []string                         `json:"bootstrap_servers,omitempty"`
+	Topics           map[string]AuditLogTopicSettings `json:"topics"`
+}
+
+type AuditLogTopicSettings struct {
+	RetentionMs int64 `json:"retention_ms"`
+}
+
+// AuditLogTopics tells which topic receives the allowed and the denied events, an empty topic drops the events
+type AuditLogTopics struct {
+	Allowed *string `json:"allowed,omitempty"`
+	Denied  *string `json:"denied,omitempty"`
+}
+
+// AuditLogRouteCategories routes each category of events of the matching CRN
+type AuditLogRouteCategories struct {
+	Management  *AuditLogTopics `json:"management,omitempty"`
+	Authorize   *AuditLogTopics `json:"authorize,omitempty"`
+	Consume     *AuditLogTopics `json:"consume,omitempty"`
+	Produce     *AuditLogTopics `json:"produce,omitempty"`
+	Interbroker *AuditLogTopics `json:"interbroker,omitempty"`
+	Other       *AuditLogTopics `json:"other,omitempty"`
+}
+
+// AuditLogConfigMetadata is set by MDS, ResourceVersion is used for optimistic concurrency on update
+type AuditLogConfigMetadata struct {
+	ResourceVersion string `json:"resource_version"`
+	UpdatedAt       string `json:"updated_at,omitempty"`
+	ModifiedBy      string `json:"modified_by,omitempty"`
+}
+
+// AuditLogRoute is the route that applies to a CRN
+type AuditLogRoute struct {
+	Route      string                  `json:"route"`
+	Categories AuditLogRouteCategories `json:"categories"`
+}
+
+// GetAuditLogConfig returns the whole audit log configuration
+// @ref https://docs.confluent.io/platform/current/security/rbac/mds-api.html#get--security-1.0-audit-config
+func (c *Client) GetAuditLogConfig() (*AuditLogConfig, error) {
+	r, err := c.DoRequest("GET", auditConfigPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var config *AuditLogConfig
+	err = json.Unmarshal(r, &config)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// UpdateAuditLogConfig replaces the audit log configuration and returns the stored one.
+// The config must carry the metadata returned by GetAuditLogConfig: MDS rejects the update with
+// ErrAuditLogConfigConflict if the config was changed by someone else in between.
+// @ref https://docs.confluent.io/platform/current/security/rbac/mds-api.html#put--security-1.0-audit-config
+func (c *Client) UpdateAuditLogConfig(config *AuditLogConfig) (*AuditLogConfig, error) {
+	if config == nil || config.Metadata == nil || config.Metadata.ResourceVersion == "" {
+		return nil, errors.New("audit log config has no resource version, get the current config first")
+	}
+
+	payloadBuf := new(bytes.Buffer)
+	err := json.NewEncoder(payloadBuf).Encode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, statusCode, status, err := c.httpClient.DoRequest("PUT", auditConfigPath, payloadBuf)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode == http.StatusConflict {
+		return nil, ErrAuditLogConfigConflict
+	}
+	if statusCode > 204 {
+		return nil, parseErrorResponse(respBody, status)
+	}
+
+	var updated *AuditLogConfig
+	err = json.Unmarshal(respBody, &updated)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// ListAuditLogRoutes returns all routes of the audit log configuration keyed by CRN pattern
+// @ref https://docs.confluent.io/platform/current/security/rbac/mds-api.html#get--security-1.0-audit-routes
+func (c *Client) ListAuditLogRoutes() (map[string]AuditLogRouteCategories, error) {
+	r, err := c.DoRequest("GET", auditRoutesPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body := struct {
+		Routes map[string]AuditLogRouteCategories `json:"routes"`
+	}{}
+	err = json.Unmarshal(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	return body.Routes, nil
+}
+
+// LookupAuditLogRoute returns the route that applies to the events of the given CRN, see CRN.String()
+// @ref https://docs.confluent.io/platform/current/security/rbac/mds-api.html#get--security-1.0-audit-lookup
+func (c *Client) LookupAuditLogRoute(crn string) (*AuditLogRoute, error) {
+	u := auditLookupPath + "?crn=" + url.QueryEscape(crn)
+	r, err := c.DoRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var route *AuditLogRoute
+	err = json.Unmarshal(r, &route)
+	if err != nil {
+		return nil, err
+	}
+	if route == nil {
+		return nil, errors.New("empty audit log route lookup response")
+	}
+	return route, nil
+}
+
+// LookupAuditLogRouteCRN is LookupAuditLogRoute with a CRN built from its elements or parsed with ParseCRN
+func (c *Client) LookupAuditLogRouteCRN(crn CRN) (*AuditLogRoute, error) {
+	if len(crn.Elements) == 0 {
+		return nil, errors.New("invalid CRN \"" + crn.String() + "\": no resource")
+	}
+	return c.LookupAuditLogRoute(crn.String())
+}
@@ -0,0 +1,159 @@
+package confluent
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const auditConfigResponse = `
+{
+	"destinations": {
+		"bootstrap_servers": ["audit.example.com:9092"],
+		"topics": {
+			"confluent-audit-log-events": {"retention_ms": 7776000000},
+			"confluent-audit-log-events_denied": {"retention_ms": 7776000000}
+		}
+	},
+	"default_topics": {
+		"allowed": "confluent-audit-log-events",
+		"denied": "confluent-audit-log-events_denied"
+	},
+	"excluded_principals": ["User:kafka"],
+	"routes": {
+		"crn:///kafka=*/topic=payments-*": {
+			"produce": {"allowed": "confluent-audit-log-events", "denied": "confluent-audit-log-events_denied"}
+		}
+	},
+	"metadata": {
+		"resource_version": "ASNFZ4mrze8BI0VniavN7w",
+		"updated_at": "2021-06-14T10:15:30Z",
+		"modified_by": "User:admin"
+	}
+}`
+
+func TestAudit_GetAuditLogConfigSuccess(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodGet, method)
+		assert.Equal(t, "/security/1.0/audit/config", uri)
+		return []byte(auditConfigResponse), 200, "200 OK", nil
+	}
+	clusterAdmin, _ := mk.NewSaramaClusterAdmin()
+	c := NewClient(&mock, &mk, clusterAdmin)
+	config, err := c.GetAuditLogConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ASNFZ4mrze8BI0VniavN7w", config.Metadata.ResourceVersion)
+		assert.Equal(t, "confluent-audit-log-events_denied", *config.DefaultTopics.Denied)
+		assert.Equal(t, "confluent-audit-log-events", *config.Routes["crn:///kafka=*/topic=payments-*"].Produce.Allowed)
+		assert.Nil(t, config.Routes["crn:///kafka=*/topic=payments-*"].Consume)
+	}
+}
+
+func TestAudit_UpdateAuditLogConfigSuccess(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodPut, method)
+		assert.Equal(t, "/security/1.0/audit/config", uri)
+		var sent AuditLogConfig
+		assert.NoError(t, json.NewDecoder(reqBody).Decode(&sent))
+		assert.Equal(t, "ASNFZ4mrze8BI0VniavN7w", sent.Metadata.ResourceVersion)
+		assert.Equal(t, []string{"User:kafka", "User:connect"}, sent.ExcludedPrincipals)
+		return []byte(auditConfigResponse), 200, "200 OK", nil
+	}
+	clusterAdmin, _ := mk.NewSaramaClusterAdmin()
+	c := NewClient(&mock, &mk, clusterAdmin)
+
+	var config AuditLogConfig
+	assert.NoError(t, json.Unmarshal([]byte(auditConfigResponse), &config))
+	config.ExcludedPrincipals = append(config.ExcludedPrincipals, "User:connect")
+	updated, err := c.UpdateAuditLogConfig(&config)
+	assert.NoError(t, err)
+	assert.NotNil(t, updated)
+}
+
+func TestAudit_UpdateAuditLogConfigConflict(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		return []byte(`{"error_code": 409, "message": "resource version mismatch"}`), 409, "409 Conflict", nil
+	}
+	clusterAdmin, _ := mk.NewSaramaClusterAdmin()
+	c := NewClient(&mock, &mk, clusterAdmin)
+
+	config := AuditLogConfig{Metadata: &AuditLogConfigMetadata{ResourceVersion: "old"}}
+	_, err := c.UpdateAuditLogConfig(&config)
+	assert.Equal(t, ErrAuditLogConfigConflict, err)
+}
+
+func TestAudit_UpdateAuditLogConfigWithoutVersion(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	clusterAdmin, _ := mk.NewSaramaClusterAdmin()
+	c := NewClient(&mock, &mk, clusterAdmin)
+
+	_, err := c.UpdateAuditLogConfig(&AuditLogConfig{})
+	assert.NotNil(t, err)
+}
+
+func TestAudit_ListAuditLogRoutesSuccess(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodGet, method)
+		assert.Equal(t, "/security/1.0/audit/routes", uri)
+		return []byte(`
+		{
+			"routes": {
+				"crn:///kafka=*/topic=payments-*": {"produce": {"allowed": "confluent-audit-log-events"}},
+				"crn:///kafka=*/group=*": {"consume": {"denied": ""}}
+			}
+		}`), 200, "200 OK", nil
+	}
+	clusterAdmin, _ := mk.NewSaramaClusterAdmin()
+	c := NewClient(&mock, &mk, clusterAdmin)
+	routes, err := c.ListAuditLogRoutes()
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, len(routes))
+		assert.Equal(t, "", *routes["crn:///kafka=*/group=*"].Consume.Denied)
+	}
+}
+
+func TestAudit_LookupAuditLogRouteSuccess(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	expectedUri := "/security/1.0/audit/lookup?crn=crn%3A%2F%2F%2Fkafka%3Dcluster-1%2Ftopic%3Dpayments-eu"
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodGet, method)
+		assert.Equal(t, expectedUri, uri)
+		return []byte(`
+		{
+			"route": "crn:///kafka=*/topic=payments-*",
+			"categories": {"produce": {"allowed": "confluent-audit-log-events"}}
+		}`), 200, "200 OK", nil
+	}
+	clusterAdmin, _ := mk.NewSaramaClusterAdmin()
+	c := NewClient(&mock, &mk, clusterAdmin)
+	crn, err := ParseCRN("crn:///kafka=cluster-1/topic=payments-eu")
+	if !assert.NoError(t, err) {
+		return
+	}
+	route, err := c.LookupAuditLogRouteCRN(*crn)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "crn:///kafka=*/topic=payments-*", route.Route)
+		assert.Equal(t, "confluent-audit-log-events", *route.Categories.Produce.Allowed)
+	}
+
+	route, err = c.LookupAuditLogRoute("crn:///kafka=cluster-1/topic=payments-eu")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "crn:///kafka=*/topic=payments-*", route.Route)
+	}
+
+	_, err = c.LookupAuditLogRouteCRN(CRN{})
+	assert.EqualError(t, err, "invalid CRN \"crn://\": no resource")
+}
@@ -0,0 +1,72 @@
+package confluent
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+type Broker struct {
+	ClusterID string  `json:"cluster_id"`
+	BrokerID  int32   `json:"broker_id"`
+	Host      string  `json:"host,omitempty"`
+	Port      int     `json:"port,omitempty"`
+	Rack      *string `json:"rack,omitempty"`
+}
+
+// ListBrokers returns the brokers of the cluster
+// @ref https://docs.confluent.io/platform/current/kafka-rest/api.html#get--clusters-cluster_id-brokers
+func (c *Client) ListBrokers(clusterId string) ([]Broker, error) {
+	u := clusterUri + "/" + clusterId + "/brokers"
+	r, err := c.DoRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body := struct {
+		Data []Broker `json:"data"`
+	}{}
+	err = json.Unmarshal(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+// ListBrokerReplicas returns the partition replicas hosted by the broker
+// @ref https://docs.confluent.io/platform/current/kafka-rest/api.html#get--clusters-cluster_id-brokers-broker_id-partition-replicas
+func (c *Client) ListBrokerReplicas(clusterId string, brokerId int32) ([]Replica, error) {
+	u := clusterUri + "/" + clusterId + "/brokers/" + strconv.Itoa(int(brokerId)) + "/partition-replicas"
+	r, err := c.DoRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body := struct {
+		Data []Replica `json:"data"`
+	}{}
+	err = json.Unmarshal(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+// UpdateBrokerConfigs updates or deletes a set of dynamic configs of the broker
+// @ref https://docs.confluent.io/platform/current/kafka-rest/api.html#post--clusters-cluster_id-brokers-broker_id-configs-alter
+func (c *Client) UpdateBrokerConfigs(clusterId string, brokerId int32, data []TopicConfig) error {
+	u := clusterUri + "/" + clusterId + "/brokers/" + strconv.Itoa(int(brokerId)) + "/configs:alter"
+
+	reqBody := struct {
+		Data []TopicConfig `json:"data"`
+	}{data}
+
+	payloadBuf := new(bytes.Buffer)
+	err := json.NewEncoder(payloadBuf).Encode(reqBody)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DoRequest("POST", u, payloadBuf)
+	return err
+}
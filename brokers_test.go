@@ -0,0 +1,34 @@
+package confluent
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokers_ListBrokers(t *testing.T) {
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodGet, method)
+		assert.Equal(t, "/kafka/v3/clusters/cluster-1/brokers", uri)
+		return []byte(`
+		{
+			"kind": "KafkaBrokerList",
+			"data": [
+				{"kind": "KafkaBroker", "cluster_id": "cluster-1", "broker_id": 1, "host": "broker-1", "port": 9092, "rack": "rack-a"},
+				{"kind": "KafkaBroker", "cluster_id": "cluster-1", "broker_id": 2, "host": "broker-2", "port": 9092, "rack": null}
+			]
+		}`), 200, "200 OK", nil
+	}
+	c := NewClient(&mock, nil, nil)
+
+	brokers, err := c.ListBrokers(clusterId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, len(brokers))
+		assert.Equal(t, int32(1), brokers[0].BrokerID)
+		assert.Equal(t, "rack-a", *brokers[0].Rack)
+		assert.Nil(t, brokers[1].Rack)
+	}
+}
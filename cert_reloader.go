@@ -0,0 +1,243 @@
+package confluent
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+)
+
+// CertificateRotationEvent is sent to the rotation callback every time the certificates files change
+type CertificateRotationEvent struct {
+	Time time.Time
+
+	// NotAfter is the expiry of the new client certificate
+	NotAfter time.Time
+
+	// Err is set when the new certificates could not be loaded, the previous ones are kept and
+	// loading is retried on the next check
+	Err error
+}
+
+// CertificateReloader serves the client certificate and verifies the server certificates with the
+// latest content of the CA, certificate and key files. Files are checked periodically and new
+// material is only used by new connections, established connections are left untouched.
+// Inputs given inline as PEM never change and are not checked.
+type CertificateReloader struct {
+	clientCert          string
+	clientKey           string
+	caCert              string
+	clientKeyPassphrase string
+	onRotation          func(CertificateRotationEvent)
+
+	mu           sync.RWMutex
+	certificate  *tls.Certificate
+	rootCAs      *x509.CertPool
+	fingerprints map[string][sha256.Size]byte
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCertificateReloader loads the certificates and checks the files every interval, a zero interval
+// disables the periodic checks and leaves reloading to Reload. onRotation may be nil.
+func NewCertificateReloader(clientCert, clientKey, caCert, clientKeyPassphrase string, interval time.Duration, onRotation func(CertificateRotationEvent)) (*CertificateReloader, error) {
+	if (clientCert == "") != (clientKey == "") {
+		return nil, errors.New("[ERROR] Both the client certificate and the client key must be provided")
+	}
+
+	r := &CertificateReloader{
+		clientCert:          clientCert,
+		clientKey:           clientKey,
+		caCert:              caCert,
+		clientKeyPassphrase: clientKeyPassphrase,
+		onRotation:          onRotation,
+		fingerprints:        make(map[string][sha256.Size]byte),
+		stop:                make(chan struct{}),
+	}
+
+	fingerprints, err := r.readFingerprints()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(fingerprints); err != nil {
+		return nil, err
+	}
+
+	if interval > 0 && len(fingerprints) > 0 {
+		go r.watch(interval)
+	}
+	return r, nil
+}
+
+// TLSConfig returns a TLS config using the reloaded certificates. The server certificate is verified
+// against the reloaded CA in VerifyConnection, which unlike VerifyPeerCertificate knows the server name.
+func (r *CertificateReloader) TLSConfig(skipVerify bool) *tls.Config {
+	tlsConfig := &tls.Config{
+		GetClientCertificate: r.GetClientCertificate,
+		InsecureSkipVerify:   skipVerify,
+	}
+	if r.caCert != "" && !skipVerify {
+		// The built-in verification would use a fixed pool
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = r.VerifyConnection
+	}
+	return tlsConfig
+}
+
+func (r *CertificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.certificate == nil {
+		// No client certificate is configured
+		return &tls.Certificate{}, nil
+	}
+	return r.certificate, nil
+}
+
+// VerifyConnection verifies the server certificate chain and name against the current CA
+func (r *CertificateReloader) VerifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("[ERROR] The server did not present any certificate")
+	}
+
+	r.mu.RLock()
+	rootCAs := r.rootCAs
+	r.mu.RUnlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         rootCAs,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, c := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// Reload loads the files again if their content changed since the last successful load
+func (r *CertificateReloader) Reload() error {
+	fingerprints, err := r.readFingerprints()
+	if err == nil {
+		r.mu.RLock()
+		changed := !sameFingerprints(fingerprints, r.fingerprints)
+		r.mu.RUnlock()
+		if !changed {
+			return nil
+		}
+		err = r.load(fingerprints)
+	}
+
+	event := CertificateRotationEvent{
+		Time: time.Now(),
+		Err:  err,
+	}
+	if err == nil {
+		event.NotAfter = r.notAfter()
+	} else {
+		log.Printf("[WARN] Unable to reload the certificates, keeping the previous ones: %s", err)
+	}
+	if r.onRotation != nil {
+		r.onRotation(event)
+	}
+	return err
+}
+
+// Close stops the periodic checks
+func (r *CertificateReloader) Close() {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+func (r *CertificateReloader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = r.Reload()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *CertificateReloader) load(fingerprints map[string][sha256.Size]byte) error {
+	var certificate *tls.Certificate
+	if r.clientCert != "" {
+		cert, err := loadX509KeyPair(r.clientCert, r.clientKey, r.clientKeyPassphrase)
+		if err != nil {
+			return err
+		}
+		certificate = &cert
+	}
+
+	var rootCAs *x509.CertPool
+	if r.caCert != "" {
+		pool, err := loadCertPool(r.caCert)
+		if err != nil {
+			return err
+		}
+		rootCAs = pool
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.certificate = certificate
+	r.rootCAs = rootCAs
+	r.fingerprints = fingerprints
+	return nil
+}
+
+func (r *CertificateReloader) notAfter() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.certificate == nil || len(r.certificate.Certificate) == 0 {
+		return time.Time{}
+	}
+	leaf, err := x509.ParseCertificate(r.certificate.Certificate[0])
+	if err != nil {
+		return time.Time{}
+	}
+	return leaf.NotAfter
+}
+
+// readFingerprints hashes the inputs that are files, inline PEM inputs are skipped
+func (r *CertificateReloader) readFingerprints() (map[string][sha256.Size]byte, error) {
+	fingerprints := make(map[string][sha256.Size]byte)
+	for _, input := range []string{r.clientCert, r.clientKey, r.caCert} {
+		if input == "" {
+			continue
+		}
+		if block, _ := pem.Decode([]byte(input)); block != nil {
+			continue
+		}
+		content, err := ioutil.ReadFile(input)
+		if err != nil {
+			return nil, err
+		}
+		fingerprints[input] = sha256.Sum256(content)
+	}
+	return fingerprints, nil
+}
+
+func sameFingerprints(a, b map[string][sha256.Size]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		w, ok := b[k]
+		if !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+package confluent
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+// newTestCertificate issues a certificate signed by the parent, or self-signed when parent is nil
+func newTestCertificate(t *testing.T, commonName string, notAfter time.Time, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	issuer, issuerKey := template, key
+	if parent != nil {
+		issuer, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})),
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCertReloader_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	first := newTestCertificate(t, "client", time.Now().Add(time.Hour), nil)
+	writeFile(t, certPath, first.certPEM)
+	writeFile(t, keyPath, first.keyPEM)
+
+	var events []CertificateRotationEvent
+	r, err := NewCertificateReloader(certPath, keyPath, "", "", 0, func(e CertificateRotationEvent) {
+		events = append(events, e)
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+
+	cert, err := r.GetClientCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, first.cert.Raw, cert.Certificate[0])
+
+	// Nothing changed
+	assert.NoError(t, r.Reload())
+	assert.Empty(t, events)
+
+	// The certificate is written before the key: the pair does not match yet and the previous one is kept
+	second := newTestCertificate(t, "client", time.Now().Add(2*time.Hour), nil)
+	writeFile(t, certPath, second.certPEM)
+	assert.NotNil(t, r.Reload())
+	if assert.Equal(t, 1, len(events)) {
+		assert.NotNil(t, events[0].Err)
+	}
+	cert, _ = r.GetClientCertificate(nil)
+	assert.Equal(t, first.cert.Raw, cert.Certificate[0])
+
+	writeFile(t, keyPath, second.keyPEM)
+	assert.NoError(t, r.Reload())
+	if assert.Equal(t, 2, len(events)) {
+		assert.NoError(t, events[1].Err)
+		assert.Equal(t, second.cert.NotAfter, events[1].NotAfter)
+	}
+	cert, _ = r.GetClientCertificate(nil)
+	assert.Equal(t, second.cert.Raw, cert.Certificate[0])
+}
+
+func TestCertReloader_PeriodicCheck(t *testing.T) {
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	writeFile(t, caPath, newTestCertificate(t, "ca-1", time.Now().Add(time.Hour), nil).certPEM)
+
+	events := make(chan CertificateRotationEvent, 1)
+	r, err := NewCertificateReloader("", "", caPath, "", 10*time.Millisecond, func(e CertificateRotationEvent) {
+		events <- e
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+
+	writeFile(t, caPath, newTestCertificate(t, "ca-2", time.Now().Add(time.Hour), nil).certPEM)
+	select {
+	case e := <-events:
+		assert.NoError(t, e.Err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the CA change was not detected")
+	}
+}
+
+func TestCertReloader_VerifyConnection(t *testing.T) {
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	ca := newTestCertificate(t, "ca-1", time.Now().Add(time.Hour), nil)
+	server := newTestCertificate(t, "broker.example.com", time.Now().Add(time.Hour), ca)
+	writeFile(t, caPath, ca.certPEM)
+
+	r, err := NewCertificateReloader("", "", caPath, "", 0, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+
+	tlsConfig := r.TLSConfig(false)
+	assert.True(t, tlsConfig.InsecureSkipVerify, "verification is done by VerifyConnection")
+	assert.NotNil(t, tlsConfig.VerifyConnection)
+	assert.Nil(t, r.TLSConfig(true).VerifyConnection)
+
+	state := tls.ConnectionState{ServerName: "broker.example.com", PeerCertificates: []*x509.Certificate{server.cert}}
+	assert.NoError(t, tlsConfig.VerifyConnection(state))
+
+	state.ServerName = "other.example.com"
+	assert.NotNil(t, tlsConfig.VerifyConnection(state))
+
+	// The CA rotates: certificates of the previous CA are not trusted anymore
+	writeFile(t, caPath, newTestCertificate(t, "ca-2", time.Now().Add(time.Hour), nil).certPEM)
+	assert.NoError(t, r.Reload())
+	state.ServerName = "broker.example.com"
+	assert.NotNil(t, tlsConfig.VerifyConnection(state))
+}
+
+func TestCertReloader_NewKafkaConfig(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	client := newTestCertificate(t, "client", time.Now().Add(time.Hour), nil)
+	writeFile(t, certPath, client.certPEM)
+	writeFile(t, keyPath, client.keyPEM)
+
+	config := Config{Timeout: 10, TLSEnabled: true, ClientCert: certPath, ClientCertKey: keyPath, CertReloadInterval: 60}
+	kc, err := config.newKafkaConfig()
+	if assert.NoError(t, err) {
+		defer config.certReloader.Close()
+		assert.True(t, kc.Net.TLS.Enable)
+		assert.NotNil(t, kc.Net.TLS.Config.GetClientCertificate)
+		cert, err := kc.Net.TLS.Config.GetClientCertificate(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, client.cert.Raw, cert.Certificate[0])
+	}
+}
+
+func TestCertReloader_NewDefaultSaramaClientError(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	client := newTestCertificate(t, "client", time.Now().Add(time.Hour), nil)
+	writeFile(t, certPath, client.certPEM)
+	writeFile(t, keyPath, client.keyPEM)
+
+	config := Config{
+		BootstrapServers: &[]string{"127.0.0.1:1"}, Timeout: 1,
+		TLSEnabled: true, ClientCert: certPath, ClientCertKey: keyPath, CertReloadInterval: 60,
+	}
+	if _, err := config.NewTLSConfig(); !assert.NoError(t, err) {
+		return
+	}
+	reloader := config.certReloader
+
+	// The reloader is stopped when the brokers cannot be reached
+	_, _, err := NewDefaultSaramaClient(&config)
+	assert.Error(t, err)
+	assert.Nil(t, config.certReloader)
+	select {
+	case <-reloader.stop:
+	default:
+		t.Error("the certificate reloader should be stopped")
+	}
+}
@@ -0,0 +1,495 @@
+package confluent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	envPrefix = "CONFLUENT_"
+
+	// Timeout used when the loaded configuration has no request.timeout.ms
+	defaultLoadedTimeout = 120
+)
+
+// ClientConfig is the configuration of the kafka client and of the Confluent API client, see LoadClientConfig
+type ClientConfig struct {
+	Kafka Config
+
+	// BaseUrl, Username and Password are the settings of NewDefaultHttpClient
+	BaseUrl  string
+	Username string
+	Password string
+}
+
+// NewHttpClient returns the client of the Confluent API
+func (cc *ClientConfig) NewHttpClient() *DefaultHttpClient {
+	return NewDefaultHttpClient(cc.BaseUrl, cc.Username, cc.Password)
+}
+
+// LoadClientConfig loads the configuration from the files, then from the CONFLUENT_* environment variables.
+//
+// The files are Java properties files, e.g. the client.properties of the Java clients, or YAML and JSON files
+// chosen by extension (.yaml, .yml, .json, anything else is read as properties). YAML and JSON files hold the
+// same properties, nested keys being joined with dots and lists with commas. Properties are applied in this order,
+// the last one wins:
+//   - the files, in the given order
+//   - the environment variables: CONFLUENT_SASL_JAAS_CONFIG sets sasl.jaas.config, CONFLUENT_USER and
+//     CONFLUENT_PASSWORD set the Confluent API credentials. Empty variables are ignored.
+//
+// The "confluent." prefix of the properties is optional. Supported properties:
+//   - bootstrap.servers, security.protocol, sasl.mechanism, sasl.jaas.config (PlainLoginModule,
+//     ScramLoginModule and Krb5LoginModule), sasl.username, sasl.password, sasl.kerberos.service.name,
+//     request.timeout.ms
+//   - ssl.truststore.location, ssl.truststore.type, ssl.truststore.password, ssl.truststore.certificates,
+//     ssl.keystore.location, ssl.keystore.type, ssl.keystore.password, ssl.keystore.certificate.chain,
+//     ssl.keystore.key, ssl.key.password, and their librdkafka equivalents ssl.ca.location,
+//     ssl.certificate.location, ssl.key.location, enable.ssl.certificate.verification
+//   - kafka.version, see Config.KafkaVersion
+//   - metadata.bootstrap.server.urls, metadata.basic.auth.user.info (user:password), metadata.username and
+//     metadata.password for the Confluent API
+//
+// Other properties, such as the producer and consumer settings, are ignored.
+func LoadClientConfig(paths ...string) (*ClientConfig, error) {
+	properties := make(map[string]string)
+	for _, path := range paths {
+		fileProperties, err := readConfigFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("[ERROR] Unable to load the configuration file %s: %w", path, err)
+		}
+		mergeProperties(properties, fileProperties)
+	}
+	mergeProperties(properties, environmentProperties(os.Environ()))
+
+	return newClientConfig(properties)
+}
+
+func mergeProperties(dst, src map[string]string) {
+	for k, v := range src {
+		dst[strings.TrimPrefix(strings.ToLower(k), "confluent.")] = v
+	}
+}
+
+func readConfigFile(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(content, &values); err != nil {
+			return nil, err
+		}
+		return flattenProperties(values), nil
+	case ".json":
+		var values map[string]interface{}
+		if err := json.Unmarshal(content, &values); err != nil {
+			return nil, err
+		}
+		return flattenProperties(values), nil
+	}
+	return parseProperties(string(content))
+}
+
+// environmentProperties maps CONFLUENT_SASL_JAAS_CONFIG to sasl.jaas.config
+func environmentProperties(environ []string) map[string]string {
+	properties := make(map[string]string)
+	for _, kv := range environ {
+		i := strings.Index(kv, "=")
+		if i < 0 || !strings.HasPrefix(kv, envPrefix) || kv[i+1:] == "" {
+			continue
+		}
+		name, value := kv[len(envPrefix):i], kv[i+1:]
+		switch name {
+		case "USER":
+			properties["metadata.username"] = value
+		case "PASSWORD":
+			properties["metadata.password"] = value
+		default:
+			properties[strings.ReplaceAll(strings.ToLower(name), "_", ".")] = value
+		}
+	}
+	return properties
+}
+
+func flattenProperties(values map[string]interface{}) map[string]string {
+	properties := make(map[string]string)
+	var flatten func(prefix string, value interface{})
+	flatten = func(prefix string, value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				flatten(prefix+k+".", child)
+			}
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			properties[strings.TrimSuffix(prefix, ".")] = strings.Join(items, ",")
+		case nil:
+		case float64:
+			// JSON numbers
+			properties[strings.TrimSuffix(prefix, ".")] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			properties[strings.TrimSuffix(prefix, ".")] = fmt.Sprint(v)
+		}
+	}
+	flatten("", values)
+	return properties
+}
+
+// parseProperties parses the Java properties format: comments start with # or !, keys and values are separated
+// by =, : or whitespace, lines ending with a backslash continue on the next line
+// @ref https://docs.oracle.com/javase/8/docs/api/java/util/Properties.html#load-java.io.Reader-
+func parseProperties(content string) (map[string]string, error) {
+	properties := make(map[string]string)
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeftFunc(lines[i], unicode.IsSpace)
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for endsWithContinuation(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeftFunc(lines[i], unicode.IsSpace)
+		}
+		if endsWithContinuation(line) {
+			// Continuation on the last line
+			line = line[:len(line)-1]
+		}
+
+		keyEnd := len(line)
+		for j := 0; j < len(line); j++ {
+			if line[j] == '\\' {
+				j++
+				continue
+			}
+			if line[j] == '=' || line[j] == ':' || line[j] == ' ' || line[j] == '\t' || line[j] == '\f' {
+				keyEnd = j
+				break
+			}
+		}
+		key, rest := line[:keyEnd], line[keyEnd:]
+		rest = strings.TrimLeft(rest, " \t\f")
+		if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+			rest = strings.TrimLeft(rest[1:], " \t\f")
+		}
+
+		unescapedKey, err := unescapeProperty(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		value, err := unescapeProperty(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		properties[unescapedKey] = value
+	}
+	return properties, nil
+}
+
+// endsWithContinuation tells whether the line ends with an odd number of backslashes
+func endsWithContinuation(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", errors.New("malformed \\uxxxx encoding")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", errors.New("malformed \\uxxxx encoding")
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// jaasConfig is a JAAS login module configuration, e.g.
+// org.apache.kafka.common.security.plain.PlainLoginModule required username="alice" password="secret";
+type jaasConfig struct {
+	LoginModule string
+	Flag        string
+	Options     map[string]string
+}
+
+func parseJaasConfig(s string) (*jaasConfig, error) {
+	tokens, err := jaasTokens(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) < 2 {
+		return nil, errors.New("expected a login module and a control flag")
+	}
+
+	config := &jaasConfig{
+		LoginModule: tokens[0],
+		Flag:        tokens[1],
+		Options:     make(map[string]string),
+	}
+	for _, option := range tokens[2:] {
+		i := strings.Index(option, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid option %q, expected key=value", option)
+		}
+		config.Options[option[:i]] = option[i+1:]
+	}
+	return config, nil
+}
+
+// jaasTokens splits the JAAS configuration on whitespace up to the final semicolon, values may be quoted
+func jaasTokens(s string) ([]string, error) {
+	tokens := make([]string, 0)
+	var current strings.Builder
+	inToken, quoted, terminated := false, false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(s):
+			i++
+			current.WriteByte(s[i])
+		case c == '"':
+			quoted = !quoted
+			inToken = true
+		case quoted:
+			current.WriteByte(c)
+		case terminated && !unicode.IsSpace(rune(c)):
+			return nil, errors.New("unexpected content after ';', only one login module is supported")
+		case c == ';' || unicode.IsSpace(rune(c)):
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+			terminated = terminated || c == ';'
+		default:
+			current.WriteByte(c)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quoted value")
+	}
+	if !terminated {
+		return nil, errors.New("missing the final ';'")
+	}
+	return tokens, nil
+}
+
+func newClientConfig(properties map[string]string) (*ClientConfig, error) {
+	cc := &ClientConfig{}
+	kafka := &cc.Kafka
+	kafka.Timeout = defaultLoadedTimeout
+
+	if servers := splitList(properties["bootstrap.servers"]); len(servers) != 0 {
+		kafka.BootstrapServers = &servers
+	}
+
+	switch protocol := strings.ToUpper(properties["security.protocol"]); protocol {
+	case "", "PLAINTEXT", "SASL_PLAINTEXT":
+	case "SSL", "SASL_SSL":
+		kafka.TLSEnabled = true
+	default:
+		return nil, fmt.Errorf("[ERROR] Invalid security.protocol %q: can only be PLAINTEXT, SSL, SASL_PLAINTEXT or SASL_SSL", protocol)
+	}
+
+	if mechanism := properties["sasl.mechanism"]; mechanism != "" {
+		switch strings.ToUpper(mechanism) {
+		case "PLAIN":
+			kafka.SASLMechanism = "plain"
+		case "SCRAM-SHA-256":
+			kafka.SASLMechanism = "scram-sha256"
+		case "SCRAM-SHA-512":
+			kafka.SASLMechanism = "scram-sha512"
+		case "OAUTHBEARER":
+			kafka.SASLMechanism = "oauthbearer"
+		case "GSSAPI":
+			kafka.SASLMechanism = "gssapi"
+		default:
+			return nil, fmt.Errorf("[ERROR] Invalid sasl.mechanism %q: can only be PLAIN, SCRAM-SHA-256, SCRAM-SHA-512, OAUTHBEARER or GSSAPI", mechanism)
+		}
+	}
+
+	if jaas := properties["sasl.jaas.config"]; jaas != "" {
+		if err := kafka.applyJaasConfig(jaas); err != nil {
+			return nil, err
+		}
+	}
+	if v := properties["sasl.username"]; v != "" {
+		kafka.SASLUsername = v
+	}
+	if v := properties["sasl.password"]; v != "" {
+		kafka.SASLPassword = v
+	}
+	kafka.KerberosServiceName = properties["sasl.kerberos.service.name"]
+
+	if v := properties["request.timeout.ms"]; v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("[ERROR] Invalid request.timeout.ms %q", v)
+		}
+		kafka.Timeout = int(math.Ceil(float64(ms) / 1000))
+	}
+
+	if err := kafka.applySSLProperties(properties); err != nil {
+		return nil, err
+	}
+
+	kafka.KafkaVersion = properties["kafka.version"]
+
+	if urls := splitList(properties["metadata.bootstrap.server.urls"]); len(urls) != 0 {
+		// The API is served by every MDS node, the first one is used
+		cc.BaseUrl = strings.TrimSuffix(urls[0], "/")
+	}
+	if userInfo := properties["metadata.basic.auth.user.info"]; userInfo != "" {
+		i := strings.Index(userInfo, ":")
+		if i < 0 {
+			return nil, errors.New("[ERROR] Invalid metadata.basic.auth.user.info: expected user:password")
+		}
+		cc.Username, cc.Password = userInfo[:i], userInfo[i+1:]
+	}
+	if v := properties["metadata.username"]; v != "" {
+		cc.Username = v
+	}
+	if v := properties["metadata.password"]; v != "" {
+		cc.Password = v
+	}
+
+	return cc, nil
+}
+
+func (co *Config) applyJaasConfig(s string) error {
+	jaas, err := parseJaasConfig(s)
+	if err != nil {
+		return fmt.Errorf("[ERROR] Invalid sasl.jaas.config: %w", err)
+	}
+
+	module := jaas.LoginModule[strings.LastIndex(jaas.LoginModule, ".")+1:]
+	switch module {
+	case "PlainLoginModule", "ScramLoginModule":
+		co.SASLUsername = jaas.Options["username"]
+		co.SASLPassword = jaas.Options["password"]
+		if co.SASLMechanism == "" && module == "PlainLoginModule" {
+			co.SASLMechanism = "plain"
+		}
+	case "Krb5LoginModule":
+		if co.SASLMechanism == "" {
+			co.SASLMechanism = "gssapi"
+		}
+		principal := jaas.Options["principal"]
+		if i := strings.LastIndex(principal, "@"); i >= 0 {
+			principal, co.KerberosRealm = principal[:i], principal[i+1:]
+		}
+		co.SASLUsername = principal
+		if strings.EqualFold(jaas.Options["useKeyTab"], "true") {
+			co.KerberosKeytabPath = jaas.Options["keyTab"]
+		}
+	case "OAuthBearerLoginModule":
+		// The tokens come from Config.SASLTokenProvider
+		if co.SASLMechanism == "" {
+			co.SASLMechanism = "oauthbearer"
+		}
+	default:
+		return fmt.Errorf("[ERROR] Unsupported login module %s in sasl.jaas.config", jaas.LoginModule)
+	}
+	return nil
+}
+
+func (co *Config) applySSLProperties(properties map[string]string) error {
+	co.TruststoreType = properties["ssl.truststore.type"]
+	co.TruststorePassword = properties["ssl.truststore.password"]
+	if location := properties["ssl.truststore.location"]; location != "" {
+		if strings.EqualFold(co.TruststoreType, keystoreTypePEM) {
+			co.CACert = location
+		} else {
+			co.TruststoreLocation = location
+		}
+	}
+	if certificates := properties["ssl.truststore.certificates"]; certificates != "" {
+		co.CACert = certificates
+	}
+
+	co.KeystoreLocation = properties["ssl.keystore.location"]
+	co.KeystoreType = properties["ssl.keystore.type"]
+	co.KeystorePassword = properties["ssl.keystore.password"]
+	if co.KeystoreLocation != "" {
+		co.KeyPassword = properties["ssl.key.password"]
+	} else {
+		co.ClientCert = firstNonEmpty(properties["ssl.keystore.certificate.chain"], properties["ssl.certificate.location"])
+		co.ClientCertKey = firstNonEmpty(properties["ssl.keystore.key"], properties["ssl.key.location"])
+		co.ClientCertKeyPassphrase = properties["ssl.key.password"]
+	}
+	if caLocation := properties["ssl.ca.location"]; caLocation != "" {
+		co.CACert = caLocation
+	}
+
+	if v := properties["enable.ssl.certificate.verification"]; v != "" {
+		verify, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("[ERROR] Invalid enable.ssl.certificate.verification %q", v)
+		}
+		co.SkipTLSVerify = !verify
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package confluent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testClientProperties = `# Shared with the Java clients
+bootstrap.servers=broker-1:9093,broker-2:9093
+security.protocol=SASL_SSL
+sasl.mechanism=SCRAM-SHA-512
+sasl.jaas.config=org.apache.kafka.common.security.scram.ScramLoginModule required \
+    username="alice" \
+    password="s3cr\\\"t";
+ssl.truststore.location=/etc/kafka/ca.pem
+ssl.truststore.type=PEM
+request.timeout.ms=30500
+confluent.metadata.bootstrap.server.urls=https://mds-1:8090/,https://mds-2:8090
+confluent.metadata.basic.auth.user.info=admin:admin-secret
+! producer settings are ignored
+acks : all
+`
+
+func TestConfigLoader_Properties(t *testing.T) {
+	cc, err := LoadClientConfig(writeTestFile(t, "client.properties", testClientProperties))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"broker-1:9093", "broker-2:9093"}, *cc.Kafka.BootstrapServers)
+	assert.True(t, cc.Kafka.TLSEnabled)
+	assert.Equal(t, "scram-sha512", cc.Kafka.SASLMechanism)
+	assert.Equal(t, "alice", cc.Kafka.SASLUsername)
+	assert.Equal(t, "s3cr\"t", cc.Kafka.SASLPassword)
+	assert.Equal(t, "/etc/kafka/ca.pem", cc.Kafka.CACert)
+	assert.Equal(t, 31, cc.Kafka.Timeout)
+	assert.Equal(t, "https://mds-1:8090", cc.BaseUrl)
+	assert.Equal(t, "admin", cc.Username)
+	assert.Equal(t, "admin-secret", cc.Password)
+
+	httpClient := cc.NewHttpClient()
+	assert.Equal(t, "https://mds-1:8090", httpClient.BaseUrl)
+	assert.Equal(t, "admin", httpClient.Username)
+}
+
+func TestConfigLoader_YAMLAndJSON(t *testing.T) {
+	yamlPath := writeTestFile(t, "client.yaml", `
+bootstrap:
+  servers:
+    - broker-1:9092
+    - broker-2:9092
+sasl:
+  mechanism: PLAIN
+  jaas:
+    config: org.apache.kafka.common.security.plain.PlainLoginModule required username="bob" password="pw";
+ssl:
+  ca:
+    location: /etc/kafka/ca.pem
+request.timeout.ms: 10000
+kafka.version: auto
+`)
+	jsonPath := writeTestFile(t, "override.json", `{"request.timeout.ms": 60000, "security": {"protocol": "SASL_SSL"}}`)
+
+	cc, err := LoadClientConfig(yamlPath, jsonPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"broker-1:9092", "broker-2:9092"}, *cc.Kafka.BootstrapServers)
+	assert.Equal(t, "plain", cc.Kafka.SASLMechanism)
+	assert.Equal(t, "bob", cc.Kafka.SASLUsername)
+	assert.Equal(t, "/etc/kafka/ca.pem", cc.Kafka.CACert)
+	assert.Equal(t, "auto", cc.Kafka.KafkaVersion)
+	assert.Equal(t, 60, cc.Kafka.Timeout, "the last file wins")
+	assert.True(t, cc.Kafka.TLSEnabled)
+}
+
+func TestConfigLoader_EnvironmentPrecedence(t *testing.T) {
+	for k, v := range map[string]string{
+		"CONFLUENT_SASL_MECHANISM":    "SCRAM-SHA-256",
+		"CONFLUENT_BOOTSTRAP_SERVERS": "broker-3:9093",
+		"CONFLUENT_USER":              "carol",
+		"CONFLUENT_PASSWORD":          "carol-secret",
+		"CONFLUENT_SSL_KEY_PASSWORD":  "",
+	} {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	cc, err := LoadClientConfig(writeTestFile(t, "client.properties", testClientProperties))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"broker-3:9093"}, *cc.Kafka.BootstrapServers)
+	assert.Equal(t, "scram-sha256", cc.Kafka.SASLMechanism)
+	assert.Equal(t, "alice", cc.Kafka.SASLUsername)
+	assert.Equal(t, "carol", cc.Username)
+	assert.Equal(t, "carol-secret", cc.Password)
+}
+
+func TestConfigLoader_Kerberos(t *testing.T) {
+	cc, err := newClientConfig(map[string]string{
+		"security.protocol":          "SASL_PLAINTEXT",
+		"sasl.kerberos.service.name": "kafka-prod",
+		"sasl.jaas.config":           `com.sun.security.auth.module.Krb5LoginModule required useKeyTab=true storeKey=true keyTab="/etc/security/kafka.keytab" principal="kafka-client@EXAMPLE.COM";`,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "gssapi", cc.Kafka.SASLMechanism)
+	assert.Equal(t, "kafka-client", cc.Kafka.SASLUsername)
+	assert.Equal(t, "EXAMPLE.COM", cc.Kafka.KerberosRealm)
+	assert.Equal(t, "/etc/security/kafka.keytab", cc.Kafka.KerberosKeytabPath)
+	assert.Equal(t, "kafka-prod", cc.Kafka.KerberosServiceName)
+	assert.False(t, cc.Kafka.TLSEnabled)
+}
+
+func TestConfigLoader_Stores(t *testing.T) {
+	cc, err := newClientConfig(map[string]string{
+		"security.protocol":       "SSL",
+		"ssl.truststore.location": "/etc/kafka/truststore.jks",
+		"ssl.truststore.password": "changeit",
+		"ssl.keystore.location":   "/etc/kafka/keystore.p12",
+		"ssl.keystore.type":       "PKCS12",
+		"ssl.keystore.password":   "changeit",
+		"ssl.key.password":        "keypass",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "/etc/kafka/truststore.jks", cc.Kafka.TruststoreLocation)
+	assert.Equal(t, "changeit", cc.Kafka.TruststorePassword)
+	assert.Equal(t, "/etc/kafka/keystore.p12", cc.Kafka.KeystoreLocation)
+	assert.Equal(t, "PKCS12", cc.Kafka.KeystoreType)
+	assert.Equal(t, "keypass", cc.Kafka.KeyPassword)
+	assert.Empty(t, cc.Kafka.ClientCertKeyPassphrase)
+	assert.Empty(t, cc.Kafka.CACert)
+}
+
+func TestConfigLoader_Errors(t *testing.T) {
+	for name, properties := range map[string]map[string]string{
+		"protocol":       {"security.protocol": "TLS"},
+		"mechanism":      {"sasl.mechanism": "DIGEST-MD5"},
+		"jaas semicolon": {"sasl.jaas.config": `org.apache.kafka.common.security.plain.PlainLoginModule required username="a"`},
+		"jaas module":    {"sasl.jaas.config": `com.example.CustomLoginModule required;`},
+		"timeout":        {"request.timeout.ms": "soon"},
+		"user info":      {"metadata.basic.auth.user.info": "admin"},
+	} {
+		_, err := newClientConfig(properties)
+		assert.NotNil(t, err, name)
+	}
+
+	_, err := LoadClientConfig(filepath.Join(t.TempDir(), "missing.properties"))
+	assert.NotNil(t, err)
+}
+
+func TestConfigLoader_ParseProperties(t *testing.T) {
+	properties, err := parseProperties("a=1\nb : 2\nc 3\n  # comment\nd=x\\\\\ne=\\u0041\\tB\nf\\=g=h\nlast=end\\")
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{
+			"a":    "1",
+			"b":    "2",
+			"c":    "3",
+			"d":    "x\\",
+			"e":    "A\tB",
+			"f=g":  "h",
+			"last": "end",
+		}, properties)
+	}
+}
@@ -15,6 +15,9 @@ type TopicConfig struct {
 	IsSensitive bool       `json:"is_sensitive,omitempty"`
 	Source      string     `json:"source,omitempty"`
 	Synonyms    []Synonyms `json:"synonyms,omitempty"`
+
+	// Operation is "DELETE" to reset the config with UpdateTopicConfigs and UpdateBrokerConfigs, it is set otherwise
+	Operation string `json:"operation,omitempty"`
 }
 
 type Synonyms struct {
@@ -21,6 +21,12 @@ type Client struct {
 
 	//saramaClient provider Kafka Admin client to connect to Kafka brokers, init and release from Sarama client.
 	saramaClusterAdmin SaramaClusterAdmin
+
+	// placementSeed shuffles the brokers with the same load when placing new replicas
+	placementSeed int64
+
+	// certReloader reloads the certificates of the REST client taken from the kafka config, stopped by Close
+	certReloader *CertificateReloader
 }
 
 type ErrorResponse struct {
@@ -47,3 +53,12 @@ func NewClient(httpClient HttpClient, saramaClient SaramaClient, saramaClusterAd
 		saramaClusterAdmin: saramaClusterAdmin,
 	}
 }
+
+// SupportsAPI tells whether all brokers of the cluster support the version of the Kafka API, see
+// https://kafka.apache.org/protocol#protocol_api_keys for the api keys. It is always false without a kafka client.
+func (c *Client) SupportsAPI(apiKey int, version int) bool {
+	if c.saramaClient == nil {
+		return false
+	}
+	return c.saramaClient.SupportsAPI(apiKey, version)
+}
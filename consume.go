@@ -0,0 +1,210 @@
+package confluent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const defaultReadIdleTimeout = 5 * time.Second
+
+// Record is a record read from a partition
+type Record struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+	Headers   []RecordHeader
+	Key       []byte
+	Value     []byte
+	// DecodedKey and DecodedValue are set by the deserializers of the ReadRecordsOptions
+	DecodedKey   interface{}
+	DecodedValue interface{}
+}
+
+// Deserializer decodes the key or the value of the records of a topic
+type Deserializer interface {
+	Deserialize(topic string, data []byte) (interface{}, error)
+}
+
+// DeserializerFunc is a function used as a Deserializer
+type DeserializerFunc func(topic string, data []byte) (interface{}, error)
+
+func (f DeserializerFunc) Deserialize(topic string, data []byte) (interface{}, error) {
+	return f(topic, data)
+}
+
+var (
+	// StringDeserializer decodes the data as a string
+	StringDeserializer = DeserializerFunc(func(topic string, data []byte) (interface{}, error) {
+		return string(data), nil
+	})
+	// JSONDeserializer decodes the data as JSON, into maps, slices, strings, float64, bool or nil
+	JSONDeserializer = DeserializerFunc(func(topic string, data []byte) (interface{}, error) {
+		if data == nil {
+			return nil, nil
+		}
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		return v, err
+	})
+)
+
+type ReadRecordsOptions struct {
+	// KeyDeserializer and ValueDeserializer decode the keys and the values when not nil
+	KeyDeserializer   Deserializer
+	ValueDeserializer Deserializer
+	// Filter keeps the records for which it returns true, all the records are kept when nil
+	Filter func(r *Record) bool
+	// Limit is the maximum number of records returned, there is no limit when 0
+	Limit int
+	// IdleTimeout stops the read when no record arrives for this duration, 5 seconds by default. The last offsets of
+	// a partition may be transaction markers, which are never returned.
+	IdleTimeout time.Duration
+}
+
+// ReadRecords returns the records of the partition from the start offset up to the end offset excluded, or up to the
+// latest record when the end offset is sarama.OffsetNewest. The start offset may be sarama.OffsetOldest. No consumer
+// group is joined and no offset is committed.
+func (c *Client) ReadRecords(topic string, partition int32, startOffset, endOffset int64, options ReadRecordsOptions) ([]Record, error) {
+	if c.saramaClient == nil {
+		return nil, errors.New("reading records requires a kafka client")
+	}
+
+	oldest, err := c.saramaClient.GetOffset(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return nil, err
+	}
+	newest, err := c.saramaClient.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return nil, err
+	}
+	if startOffset == sarama.OffsetOldest || startOffset < oldest {
+		startOffset = oldest
+	}
+	if endOffset == sarama.OffsetNewest || endOffset > newest {
+		endOffset = newest
+	}
+	return c.readRecords(topic, partition, startOffset, endOffset, options)
+}
+
+// ReadRecordsFromTime returns the records of the partition from the first record at or after the time up to the
+// latest record
+func (c *Client) ReadRecordsFromTime(topic string, partition int32, from time.Time, options ReadRecordsOptions) ([]Record, error) {
+	if c.saramaClient == nil {
+		return nil, errors.New("reading records requires a kafka client")
+	}
+
+	startOffset, err := c.saramaClient.GetOffset(topic, partition, from.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	if startOffset == -1 {
+		// No record at or after the time
+		return []Record{}, nil
+	}
+	return c.ReadRecords(topic, partition, startOffset, sarama.OffsetNewest, options)
+}
+
+// TailRecords returns the last count records of the partition, fewer when the filter drops records
+func (c *Client) TailRecords(topic string, partition int32, count int, options ReadRecordsOptions) ([]Record, error) {
+	if c.saramaClient == nil {
+		return nil, errors.New("reading records requires a kafka client")
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid count %d", count)
+	}
+
+	newest, err := c.saramaClient.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReadRecords(topic, partition, newest-int64(count), newest, options)
+}
+
+// readRecords consumes the partition from the start offset, which must exist, up to the end offset excluded
+func (c *Client) readRecords(topic string, partition int32, startOffset, endOffset int64, options ReadRecordsOptions) ([]Record, error) {
+	records := make([]Record, 0)
+	if startOffset >= endOffset {
+		return records, nil
+	}
+	idleTimeout := options.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultReadIdleTimeout
+	}
+
+	consumer, err := c.saramaClient.NewConsumer()
+	if err != nil {
+		return nil, err
+	}
+	defer consumer.Close()
+	pc, err := consumer.ConsumePartition(topic, partition, startOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer pc.Close()
+
+	timer := time.NewTimer(idleTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case msg, ok := <-pc.Messages():
+			if !ok || msg.Offset >= endOffset {
+				return records, nil
+			}
+			record, err := newRecord(msg, options)
+			if err != nil {
+				return records, err
+			}
+			if options.Filter == nil || options.Filter(record) {
+				records = append(records, *record)
+				if options.Limit > 0 && len(records) == options.Limit {
+					return records, nil
+				}
+			}
+			if msg.Offset == endOffset-1 {
+				return records, nil
+			}
+
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(idleTimeout)
+		case err := <-pc.Errors():
+			return records, err
+		case <-timer.C:
+			return records, nil
+		}
+	}
+}
+
+func newRecord(msg *sarama.ConsumerMessage, options ReadRecordsOptions) (*Record, error) {
+	record := &Record{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp,
+		Headers:   make([]RecordHeader, 0, len(msg.Headers)),
+		Key:       msg.Key,
+		Value:     msg.Value,
+	}
+	for _, h := range msg.Headers {
+		record.Headers = append(record.Headers, RecordHeader{Name: string(h.Key), Value: h.Value})
+	}
+
+	var err error
+	if options.KeyDeserializer != nil {
+		if record.DecodedKey, err = options.KeyDeserializer.Deserialize(msg.Topic, msg.Key); err != nil {
+			return nil, fmt.Errorf("unable to decode the key of the record %d: %w", msg.Offset, err)
+		}
+	}
+	if options.ValueDeserializer != nil {
+		if record.DecodedValue, err = options.ValueDeserializer.Deserialize(msg.Topic, msg.Value); err != nil {
+			return nil, fmt.Errorf("unable to decode the value of the record %d: %w", msg.Offset, err)
+		}
+	}
+	return record, nil
+}
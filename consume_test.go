@@ -0,0 +1,219 @@
+package confluent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockConsumer consumes the messages of a single partition
+type MockConsumer struct {
+	Messages []*sarama.ConsumerMessage
+	Err      error
+	// StartOffsets are the offsets the partition consumers start from
+	StartOffsets []int64
+}
+
+func (mc *MockConsumer) Topics() ([]string, error) {
+	return nil, nil
+}
+
+func (mc *MockConsumer) Partitions(topic string) ([]int32, error) {
+	return nil, nil
+}
+
+func (mc *MockConsumer) ConsumePartition(topic string, partition int32, offset int64) (sarama.PartitionConsumer, error) {
+	mc.StartOffsets = append(mc.StartOffsets, offset)
+	pc := &MockPartitionConsumer{
+		messages: make(chan *sarama.ConsumerMessage, len(mc.Messages)),
+		errors:   make(chan *sarama.ConsumerError, 1),
+	}
+	for _, msg := range mc.Messages {
+		if msg.Offset >= offset {
+			pc.messages <- msg
+		}
+	}
+	if mc.Err != nil {
+		pc.errors <- &sarama.ConsumerError{Topic: topic, Partition: partition, Err: mc.Err}
+	}
+	return pc, nil
+}
+
+func (mc *MockConsumer) HighWaterMarks() map[string]map[int32]int64 {
+	return nil
+}
+
+func (mc *MockConsumer) Close() error {
+	return nil
+}
+
+type MockPartitionConsumer struct {
+	messages chan *sarama.ConsumerMessage
+	errors   chan *sarama.ConsumerError
+}
+
+func (mpc *MockPartitionConsumer) AsyncClose() {}
+
+func (mpc *MockPartitionConsumer) Close() error {
+	return nil
+}
+
+func (mpc *MockPartitionConsumer) Messages() <-chan *sarama.ConsumerMessage {
+	return mpc.messages
+}
+
+func (mpc *MockPartitionConsumer) Errors() <-chan *sarama.ConsumerError {
+	return mpc.errors
+}
+
+func (mpc *MockPartitionConsumer) HighWaterMarkOffset() int64 {
+	return 0
+}
+
+// newConsumeMockClient returns a client whose partition 3 of topic-1 holds the records of offsets 1 to 5, with the
+// timestamps 1 to 5 seconds and the keys "b" and "a" in turn
+func newConsumeMockClient() (*MockKafkaClient, *MockConsumer) {
+	consumer := &MockConsumer{}
+	for i := 1; i <= 5; i++ {
+		msg := &sarama.ConsumerMessage{
+			Topic:     "topic-1",
+			Partition: 3,
+			Offset:    int64(i),
+			Key:       []byte{byte('a' + i%2)},
+			Value:     []byte(`{"n":` + string(rune('0'+i)) + `}`),
+			Timestamp: time.Unix(int64(i), 0),
+		}
+		if i == 1 {
+			msg.Headers = []*sarama.RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}}
+		}
+		consumer.Messages = append(consumer.Messages, msg)
+	}
+
+	mk := &MockKafkaClient{Consumer: consumer}
+	mk.GetOffsetFn = func(topic string, partitionId int32, time int64) (int64, error) {
+		switch time {
+		case sarama.OffsetOldest:
+			return 1, nil
+		case sarama.OffsetNewest:
+			return 6, nil
+		case 4000:
+			return 4, nil
+		}
+		return -1, nil
+	}
+	return mk, consumer
+}
+
+func TestConsume_ReadRecords(t *testing.T) {
+	mk, consumer := newConsumeMockClient()
+	c := NewClient(&MockHttpClient{}, mk, nil)
+
+	records, err := c.ReadRecords("topic-1", 3, sarama.OffsetOldest, 3, ReadRecordsOptions{
+		KeyDeserializer:   StringDeserializer,
+		ValueDeserializer: JSONDeserializer,
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Record{
+			{
+				Topic: "topic-1", Partition: 3, Offset: 1, Timestamp: time.Unix(1, 0),
+				Headers: []RecordHeader{{Name: "trace", Value: []byte("abc")}},
+				Key:     []byte("b"), Value: []byte(`{"n":1}`),
+				DecodedKey: "b", DecodedValue: map[string]interface{}{"n": float64(1)},
+			},
+			{
+				Topic: "topic-1", Partition: 3, Offset: 2, Timestamp: time.Unix(2, 0),
+				Headers: []RecordHeader{},
+				Key:     []byte("a"), Value: []byte(`{"n":2}`),
+				DecodedKey: "a", DecodedValue: map[string]interface{}{"n": float64(2)},
+			},
+		}, records)
+	}
+
+	// The records with the key "a", from the oldest offset
+	records, err = c.ReadRecords("topic-1", 3, 0, sarama.OffsetNewest, ReadRecordsOptions{
+		Filter: func(r *Record) bool { return string(r.Key) == "a" },
+	})
+	if assert.NoError(t, err) && assert.Len(t, records, 2) {
+		assert.Equal(t, int64(2), records[0].Offset)
+		assert.Equal(t, int64(4), records[1].Offset)
+	}
+	assert.Equal(t, []int64{1, 1}, consumer.StartOffsets)
+
+	_, err = c.ReadRecords("topic-1", 3, 1, 6, ReadRecordsOptions{
+		ValueDeserializer: DeserializerFunc(func(topic string, data []byte) (interface{}, error) {
+			return nil, errors.New("unknown magic byte")
+		}),
+	})
+	assert.EqualError(t, err, "unable to decode the value of the record 1: unknown magic byte")
+
+	// Nothing to read
+	consumer.StartOffsets = nil
+	records, err = c.ReadRecords("topic-1", 3, 6, sarama.OffsetNewest, ReadRecordsOptions{})
+	if assert.NoError(t, err) {
+		assert.Empty(t, records)
+		assert.Empty(t, consumer.StartOffsets)
+	}
+
+	consumer.Messages, consumer.Err = nil, sarama.ErrOffsetOutOfRange
+	_, err = c.ReadRecords("topic-1", 3, 1, 6, ReadRecordsOptions{})
+	assert.EqualError(t, err, "kafka: error while consuming topic-1/3: "+sarama.ErrOffsetOutOfRange.Error())
+
+	_, err = NewClient(&MockHttpClient{}, nil, nil).ReadRecords("topic-1", 3, 1, 6, ReadRecordsOptions{})
+	assert.Equal(t, errors.New("reading records requires a kafka client"), err)
+}
+
+func TestConsume_ReadRecordsIdleTimeout(t *testing.T) {
+	// The offset 6 is a transaction marker which is never returned
+	mk, _ := newConsumeMockClient()
+	mk.GetOffsetFn = func(topic string, partitionId int32, time int64) (int64, error) {
+		if time == sarama.OffsetOldest {
+			return 1, nil
+		}
+		return 7, nil
+	}
+	c := NewClient(&MockHttpClient{}, mk, nil)
+
+	records, err := c.ReadRecords("topic-1", 3, 1, sarama.OffsetNewest, ReadRecordsOptions{IdleTimeout: 10 * time.Millisecond})
+	if assert.NoError(t, err) {
+		assert.Len(t, records, 5)
+	}
+}
+
+func TestConsume_ReadRecordsFromTime(t *testing.T) {
+	mk, _ := newConsumeMockClient()
+	c := NewClient(&MockHttpClient{}, mk, nil)
+
+	records, err := c.ReadRecordsFromTime("topic-1", 3, time.Unix(4, 0), ReadRecordsOptions{Limit: 1})
+	if assert.NoError(t, err) && assert.Len(t, records, 1) {
+		assert.Equal(t, int64(4), records[0].Offset)
+	}
+
+	records, err = c.ReadRecordsFromTime("topic-1", 3, time.Unix(10, 0), ReadRecordsOptions{})
+	if assert.NoError(t, err) {
+		assert.Empty(t, records)
+	}
+}
+
+func TestConsume_TailRecords(t *testing.T) {
+	mk, consumer := newConsumeMockClient()
+	c := NewClient(&MockHttpClient{}, mk, nil)
+
+	records, err := c.TailRecords("topic-1", 3, 2, ReadRecordsOptions{})
+	if assert.NoError(t, err) && assert.Len(t, records, 2) {
+		assert.Equal(t, int64(4), records[0].Offset)
+		assert.Equal(t, int64(5), records[1].Offset)
+	}
+
+	// More records than the partition holds
+	records, err = c.TailRecords("topic-1", 3, 20, ReadRecordsOptions{})
+	if assert.NoError(t, err) {
+		assert.Len(t, records, 5)
+	}
+	assert.Equal(t, []int64{4, 1}, consumer.StartOffsets)
+
+	_, err = c.TailRecords("topic-1", 3, 0, ReadRecordsOptions{})
+	assert.Equal(t, errors.New("invalid count 0"), err)
+}
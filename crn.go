@@ -0,0 +1,173 @@
+package confluent
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	crnScheme   = "crn://"
+	crnWildcard = "*"
+)
+
+// CRN is a Confluent Resource Name, e.g. crn://mds.example.com/kafka=cluster-1/topic=topic-X
+// A name can be "*" to match any name, or end with "*" to match every name with that prefix.
+// @ref https://docs.confluent.io/platform/current/security/audit-logs/audit-logs-concepts.html#crn
+type CRN struct {
+	// Authority is the MDS host, empty for crn:///
+	Authority string
+	Elements  []CRNElement
+}
+
+type CRNElement struct {
+	Type string
+	Name string
+}
+
+// Map the RBAC and ACL resource types, once normalized, to the CRN element types
+var crnResourceTypes = map[string]string{
+	"TOPIC":           "topic",
+	"GROUP":           "group",
+	"TRANSACTIONALID": "transactional-id",
+	"DELEGATIONTOKEN": "delegation-token",
+	"SUBJECT":         "subject",
+	"CONNECTOR":       "connector",
+}
+
+// ParseCRN parses a CRN such as crn:///kafka=cluster-1/topic=topic-X
+func ParseCRN(s string) (*CRN, error) {
+	if !strings.HasPrefix(s, crnScheme) {
+		return nil, errors.New("invalid CRN \"" + s + "\": must start with " + crnScheme)
+	}
+	rest := strings.TrimPrefix(s, crnScheme)
+	parts := strings.Split(rest, "/")
+
+	crn := &CRN{
+		Authority: parts[0],
+	}
+	for _, part := range parts[1:] {
+		i := strings.Index(part, "=")
+		if i <= 0 || i == len(part)-1 {
+			return nil, errors.New("invalid CRN \"" + s + "\": element \"" + part + "\" must be type=name")
+		}
+		crn.Elements = append(crn.Elements, CRNElement{
+			Type: part[:i],
+			Name: part[i+1:],
+		})
+	}
+	if len(crn.Elements) == 0 {
+		return nil, errors.New("invalid CRN \"" + s + "\": no resource")
+	}
+	return crn, nil
+}
+
+func (crn CRN) String() string {
+	var b strings.Builder
+	b.WriteString(crnScheme)
+	b.WriteString(crn.Authority)
+	for _, e := range crn.Elements {
+		b.WriteString("/")
+		b.WriteString(e.Type)
+		b.WriteString("=")
+		b.WriteString(e.Name)
+	}
+	return b.String()
+}
+
+// Child returns a copy of the CRN with one more element
+func (crn CRN) Child(elementType, name string) CRN {
+	elements := make([]CRNElement, len(crn.Elements), len(crn.Elements)+1)
+	copy(elements, crn.Elements)
+	return CRN{
+		Authority: crn.Authority,
+		Elements:  append(elements, CRNElement{Type: elementType, Name: name}),
+	}
+}
+
+// Get returns the name of the first element of the given type, e.g. Get("topic")
+func (crn CRN) Get(elementType string) (string, bool) {
+	for _, e := range crn.Elements {
+		if e.Type == elementType {
+			return e.Name, true
+		}
+	}
+	return "", false
+}
+
+// Match reports whether the pattern CRN matches the other CRN. Both must have the same element types
+// in the same order, and every pattern name must match the other name literally, as "*" or as a prefix.
+// An empty authority in the pattern matches any authority.
+func (crn CRN) Match(other CRN) bool {
+	if crn.Authority != "" && crn.Authority != other.Authority {
+		return false
+	}
+	if len(crn.Elements) != len(other.Elements) {
+		return false
+	}
+	for i, e := range crn.Elements {
+		if e.Type != other.Elements[i].Type || !crnNameMatches(e.Name, other.Elements[i].Name) {
+			return false
+		}
+	}
+	return true
+}
+
+func crnNameMatches(pattern, name string) bool {
+	if pattern == crnWildcard || pattern == name {
+		return true
+	}
+	if strings.HasSuffix(pattern, crnWildcard) {
+		return strings.HasPrefix(name, strings.TrimSuffix(pattern, crnWildcard))
+	}
+	return false
+}
+
+// CRN returns the CRN of the clusters of the scope, the Kafka cluster first
+func (cd ClusterDetails) CRN() CRN {
+	crn := CRN{}
+	if cd.Clusters.KafkaCluster != "" {
+		crn = crn.Child("kafka", cd.Clusters.KafkaCluster)
+	}
+	if cd.Clusters.ConnectCluster != "" {
+		crn = crn.Child("connect", cd.Clusters.ConnectCluster)
+	}
+	if cd.Clusters.KSqlCluster != "" {
+		crn = crn.Child("ksql", cd.Clusters.KSqlCluster)
+	}
+	if cd.Clusters.SchemaRegistryCluster != "" {
+		crn = crn.Child("schema-registry", cd.Clusters.SchemaRegistryCluster)
+	}
+	return crn
+}
+
+// CRN returns the CRN of the resource pattern in the given scope, a PREFIXED pattern ends with "*"
+func (p ResourcePattern) CRN(cd ClusterDetails) (CRN, error) {
+	return resourceCRN(cd.CRN(), p.ResourceType, p.Name, p.PatternType)
+}
+
+// CRN returns the CRN of the resource the ACL applies to, a PREFIXED pattern ends with "*"
+func (acl Acl) CRN() (CRN, error) {
+	cluster := CRN{}.Child("kafka", acl.ClusterId)
+	return resourceCRN(cluster, acl.ResourceType, acl.ResourceName, acl.PatternType)
+}
+
+// CRN parses the resource name returned by the Confluent APIs
+func (m Metadata) CRN() (*CRN, error) {
+	return ParseCRN(m.ResourceName)
+}
+
+func resourceCRN(cluster CRN, resourceType, name, patternType string) (CRN, error) {
+	t := normalizeName(resourceType)
+	// The cluster itself is the resource
+	if t == "CLUSTER" || t == "KAFKACLUSTER" {
+		return cluster, nil
+	}
+	elementType, ok := crnResourceTypes[t]
+	if !ok {
+		return CRN{}, errors.New("resource type \"" + resourceType + "\" has no CRN")
+	}
+	if normalizeName(patternType) == patternPrefixed {
+		name += crnWildcard
+	}
+	return cluster.Child(elementType, name), nil
+}
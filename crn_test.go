@@ -0,0 +1,76 @@
+package confluent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCRN_ParseAndFormat(t *testing.T) {
+	crn, err := ParseCRN("crn:///kafka=cluster-1/topic=topic-X")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", crn.Authority)
+		assert.Equal(t, []CRNElement{{Type: "kafka", Name: "cluster-1"}, {Type: "topic", Name: "topic-X"}}, crn.Elements)
+		topic, ok := crn.Get("topic")
+		assert.True(t, ok)
+		assert.Equal(t, "topic-X", topic)
+		assert.Equal(t, "crn:///kafka=cluster-1/topic=topic-X", crn.String())
+	}
+
+	crn, err = ParseCRN("crn://mds.example.com/kafka=cluster-1/connect=connect-1/connector=sink=1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "mds.example.com", crn.Authority)
+		assert.Equal(t, "sink=1", crn.Elements[2].Name)
+	}
+
+	_, err = ParseCRN("kafka=cluster-1")
+	assert.NotNil(t, err)
+	_, err = ParseCRN("crn:///kafka=")
+	assert.NotNil(t, err)
+	_, err = ParseCRN("crn:///")
+	assert.NotNil(t, err)
+}
+
+func TestCRN_Match(t *testing.T) {
+	topic, _ := ParseCRN("crn://mds.example.com/kafka=cluster-1/topic=payments-eu")
+
+	for pattern, expected := range map[string]bool{
+		"crn:///kafka=*/topic=*":                                  true,
+		"crn:///kafka=cluster-1/topic=payments-*":                 true,
+		"crn://mds.example.com/kafka=cluster-1/topic=payments-eu": true,
+		"crn://other.example.com/kafka=*/topic=*":                 false,
+		"crn:///kafka=cluster-2/topic=*":                          false,
+		"crn:///kafka=*/topic=orders-*":                           false,
+		"crn:///kafka=*/group=*":                                  false,
+		"crn:///kafka=*":                                          false,
+	} {
+		p, err := ParseCRN(pattern)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, p.Match(*topic), pattern)
+		}
+	}
+}
+
+func TestCRN_FromResources(t *testing.T) {
+	cd := ClusterDetails{Clusters: Clusters{KafkaCluster: "cluster-1", ConnectCluster: "connect-1"}}
+	assert.Equal(t, "crn:///kafka=cluster-1/connect=connect-1", cd.CRN().String())
+
+	crn, err := ResourcePattern{ResourceType: "Topic", Name: "orders-", PatternType: "PREFIXED"}.CRN(cDetails)
+	assert.NoError(t, err)
+	assert.Equal(t, "crn:///kafka=cluster-1/topic=orders-*", crn.String())
+
+	crn, err = ResourcePattern{ResourceType: "Cluster", Name: "kafka-cluster"}.CRN(cDetails)
+	assert.NoError(t, err)
+	assert.Equal(t, "crn:///kafka=cluster-1", crn.String())
+
+	crn, err = Acl{ClusterId: "cluster-1", ResourceType: "TRANSACTIONAL_ID", ResourceName: "tx", PatternType: "LITERAL"}.CRN()
+	assert.NoError(t, err)
+	assert.Equal(t, "crn:///kafka=cluster-1/transactional-id=tx", crn.String())
+
+	_, err = Acl{ClusterId: "cluster-1", ResourceType: "UNKNOWN"}.CRN()
+	assert.NotNil(t, err)
+
+	parsed, err := Metadata{ResourceName: "crn:///kafka=cluster-1/topic=topic-X"}.CRN()
+	assert.NoError(t, err)
+	assert.Equal(t, "topic-X", parsed.Elements[1].Name)
+}
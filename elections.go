@@ -0,0 +1,232 @@
+package confluent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const defaultElectionBatchSize = 100
+
+// ElectionType is the type of leader election, as in the ElectLeaders API
+type ElectionType int8
+
+const (
+	// PreferredElection elects the first replica of the partition if it is in sync
+	PreferredElection ElectionType = 0
+	// UncleanElection elects any live replica when the partition has no leader and no in-sync replica, records may be lost
+	UncleanElection ElectionType = 1
+)
+
+func (e ElectionType) String() string {
+	switch e {
+	case PreferredElection:
+		return "PREFERRED"
+	case UncleanElection:
+		return "UNCLEAN"
+	}
+	return fmt.Sprintf("ElectionType(%d)", int8(e))
+}
+
+// ElectLeaders elects the leaders of the partitions of each topic. It requires the ElectLeaders API, v0 for the
+// preferred election and v1 for the unclean election. The partitions which already have the elected leader succeed.
+// Sarama has no ElectLeaders request: it is sent to the controller on a broker connection of its own, opened outside
+// sarama with the TLS and SASL settings of the client, which does not support the "gssapi" SASL mechanism.
+func (c *Client) ElectLeaders(topicPartitions map[string][]int32, electionType ElectionType) error {
+	version := 0
+	switch electionType {
+	case PreferredElection:
+	case UncleanElection:
+		version = 1
+	default:
+		return fmt.Errorf("invalid election type %d", electionType)
+	}
+	if err := c.requireAPI(apiKeyElectLeaders, version, "ElectLeaders"); err != nil {
+		return err
+	}
+	if len(topicPartitions) == 0 {
+		return nil
+	}
+
+	results, err := c.saramaClusterAdmin.ElectLeaders(electionType, topicPartitions)
+	if err != nil {
+		return err
+	}
+	return electionResultsError(results)
+}
+
+// ElectPreferredLeaders runs the preferred election of all the partitions of the cluster whose leader is not the
+// preferred leader, batchSize partitions at a time. batchSize defaults to 100.
+func (c *Client) ElectPreferredLeaders(batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = defaultElectionBatchSize
+	}
+
+	partitions, err := c.PartitionsWithoutPreferredLeader()
+	if err != nil {
+		return err
+	}
+
+	topics := make([]string, 0, len(partitions))
+	for topic := range partitions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	errs := make([]error, 0)
+	batch := make(map[string][]int32)
+	batchCount := 0
+	elect := func() {
+		if err := c.ElectLeaders(batch, PreferredElection); err != nil {
+			errs = append(errs, err)
+		}
+		batch = make(map[string][]int32)
+		batchCount = 0
+	}
+	for _, topic := range topics {
+		for _, p := range partitions[topic] {
+			batch[topic] = append(batch[topic], p)
+			batchCount++
+			if batchCount == batchSize {
+				elect()
+			}
+		}
+	}
+	if batchCount != 0 {
+		elect()
+	}
+
+	if len(errs) != 0 {
+		return errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
+	}
+	return nil
+}
+
+// PartitionsWithoutPreferredLeader returns the partitions of each topic whose leader is not the first replica while
+// the first replica is in sync, the preferred election would move their leader
+func (c *Client) PartitionsWithoutPreferredLeader() (map[string][]int32, error) {
+	if c.saramaClient == nil {
+		return nil, errors.New("PartitionsWithoutPreferredLeader requires a kafka client")
+	}
+	if err := c.saramaClient.RefreshMetadata(); err != nil {
+		return nil, err
+	}
+	topics, err := c.saramaClient.Topics()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]int32)
+	for _, topic := range topics {
+		partitions, err := c.saramaClient.Partitions(topic)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range partitions {
+			replicas, err := c.saramaClient.Replicas(topic, p)
+			if err != nil {
+				return nil, err
+			}
+			if len(replicas) == 0 {
+				continue
+			}
+			isr, err := c.saramaClient.InSyncReplicas(topic, p)
+			if err != nil {
+				return nil, err
+			}
+			if !containsReplica(isr, replicas[0]) {
+				continue
+			}
+
+			leader, err := c.saramaClient.Leader(topic, p)
+			if err != nil && err != sarama.ErrLeaderNotAvailable {
+				return nil, err
+			}
+			if leader == nil || c.saramaClient.ID(leader) != replicas[0] {
+				result[topic] = append(result[topic], p)
+			}
+		}
+	}
+	return result, nil
+}
+
+func electionResultsError(results map[string]map[int32]sarama.KError) error {
+	errs := make([]error, 0)
+	for topic, partitions := range results {
+		for p, code := range partitions {
+			if code != sarama.ErrNoError && code != sarama.ErrElectionNotNeeded {
+				errs = append(errs, fmt.Errorf("[%s-%d]: %s", topic, p, code.Error()))
+			}
+		}
+	}
+	if len(errs) != 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
+	}
+	return nil
+}
+
+func containsReplica(replicas []int32, replica int32) bool {
+	for _, r := range replicas {
+		if r == replica {
+			return true
+		}
+	}
+	return false
+}
+
+// electLeadersRequest is the ElectLeaders request v0, which only runs the preferred election, or v1
+// @ref https://kafka.apache.org/protocol#The_Messages_ElectLeaders
+type electLeadersRequest struct {
+	version         int16
+	electionType    ElectionType
+	topicPartitions map[string][]int32
+	timeout         time.Duration
+}
+
+func (r *electLeadersRequest) apiKey() int16     { return apiKeyElectLeaders }
+func (r *electLeadersRequest) apiVersion() int16 { return r.version }
+
+func (r *electLeadersRequest) encode(e *protocolEncoder) {
+	if r.version >= 1 {
+		e.putInt8(int8(r.electionType))
+	}
+	topics := make([]string, 0, len(r.topicPartitions))
+	for topic := range r.topicPartitions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	e.putArrayLength(len(topics))
+	for _, topic := range topics {
+		e.putString(topic)
+		e.putInt32Array(r.topicPartitions[topic])
+	}
+	e.putInt32(int32(r.timeout / time.Millisecond))
+}
+
+type electLeadersResponse struct {
+	err     sarama.KError
+	results map[string]map[int32]sarama.KError
+}
+
+func (r *electLeadersResponse) decode(d *protocolDecoder, version int16) {
+	d.getInt32() // throttle time
+	if version >= 1 {
+		r.err = d.getKError()
+	}
+	r.results = make(map[string]map[int32]sarama.KError)
+	topics := d.getArrayLength()
+	for i := 0; i < topics; i++ {
+		topic := d.getString()
+		partitions := d.getArrayLength()
+		r.results[topic] = make(map[int32]sarama.KError, partitions)
+		for j := 0; j < partitions; j++ {
+			partition := d.getInt32()
+			r.results[topic][partition] = d.getKError()
+			d.getNullableString() // error message
+		}
+	}
+}
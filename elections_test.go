@@ -0,0 +1,129 @@
+package confluent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElections_ElectLeaders(t *testing.T) {
+	mk := MockKafkaClient{}
+	admin := &MockKafkaAdmin{}
+	admin.ElectLeadersFn = func(electionType ElectionType, topicPartitions map[string][]int32) (map[string]map[int32]sarama.KError, error) {
+		assert.Equal(t, UncleanElection, electionType)
+		assert.Equal(t, map[string][]int32{"topic-1": {0, 1, 2}}, topicPartitions)
+		return map[string]map[int32]sarama.KError{
+			"topic-1": {0: sarama.ErrNoError, 1: sarama.ErrElectionNotNeeded, 2: sarama.ErrEligibleLeadersNotAvailable},
+		}, nil
+	}
+	c := NewClient(&MockHttpClient{}, &mk, admin)
+
+	err := c.ElectLeaders(map[string][]int32{"topic-1": {0, 1, 2}}, UncleanElection)
+	assert.Equal(t, errors.New("[topic-1-2]: "+sarama.ErrEligibleLeadersNotAvailable.Error()+"\n"), err)
+
+	mk.SupportedAPIs = map[int]int{apiKeyElectLeaders: 0}
+	err = c.ElectLeaders(map[string][]int32{"topic-1": {0}}, UncleanElection)
+	assert.Equal(t, errors.New("ElectLeaders v1 is not supported by all brokers of the cluster"), err)
+
+	err = NewClient(&MockHttpClient{}, nil, nil).ElectLeaders(map[string][]int32{"topic-1": {0}}, PreferredElection)
+	assert.Equal(t, errors.New("ElectLeaders requires a kafka client"), err)
+}
+
+func TestElections_DefaultAdminElectLeaders(t *testing.T) {
+	broker := newProtocolTestBroker(t, map[int16]protocolTestHandler{
+		apiKeyElectLeaders: func(version int16, req *protocolDecoder, resp *protocolEncoder) {
+			electionType := PreferredElection
+			if version >= 1 {
+				electionType = ElectionType(req.getInt8())
+			}
+			assert.Equal(t, 1, req.getArrayLength())
+			assert.Equal(t, "topic-1", req.getString())
+			assert.Equal(t, []int32{0, 1}, req.getInt32Array())
+			assert.Equal(t, int32(3000), req.getInt32())
+			assert.NoError(t, req.err)
+
+			resp.putInt32(0)
+			if version >= 1 {
+				resp.putInt16(0)
+			}
+			resp.putArrayLength(1)
+			resp.putString("topic-1")
+			resp.putArrayLength(2)
+			resp.putInt32(0)
+			resp.putInt16(0)
+			resp.putNullableString(nil)
+			resp.putInt32(1)
+			if electionType == UncleanElection {
+				resp.putInt16(int16(sarama.ErrEligibleLeadersNotAvailable))
+			} else {
+				resp.putInt16(int16(sarama.ErrElectionNotNeeded))
+			}
+			resp.putNullableString(nil)
+		},
+	})
+	defer broker.Close()
+	admin := newProtocolTestAdmin(broker)
+	admin.client.Config().Admin.Timeout = 3 * time.Second
+
+	results, err := admin.ElectLeaders(PreferredElection, map[string][]int32{"topic-1": {0, 1}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]map[int32]sarama.KError{"topic-1": {0: sarama.ErrNoError, 1: sarama.ErrElectionNotNeeded}}, results)
+	}
+
+	results, err = admin.ElectLeaders(UncleanElection, map[string][]int32{"topic-1": {0, 1}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]map[int32]sarama.KError{"topic-1": {0: sarama.ErrNoError, 1: sarama.ErrEligibleLeadersNotAvailable}}, results)
+	}
+}
+
+func TestElections_ElectPreferredLeaders(t *testing.T) {
+	brokers := map[int32]*sarama.Broker{1: sarama.NewBroker("broker-1:9092"), 2: sarama.NewBroker("broker-2:9092")}
+	mk := MockKafkaClient{TopicsExpected: []string{"topic-2", "topic-1"}}
+	mk.IDFn = func(broker *sarama.Broker) int32 {
+		return int32(broker.Addr()[7] - '0')
+	}
+	mk.ReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		if topic == "topic-1" {
+			return []int32{1, 2}, nil
+		}
+		return []int32{2, 1}, nil
+	}
+	mk.InSyncReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		if topic == "topic-2" && partitionId == 2 {
+			// The preferred leader is out of sync
+			return []int32{1}, nil
+		}
+		return []int32{1, 2}, nil
+	}
+	mk.LeaderFn = func(topic string, partitionId int32) (*sarama.Broker, error) {
+		if partitionId == 1 {
+			return brokers[1], nil
+		}
+		return brokers[2], nil
+	}
+	batches := make([]map[string][]int32, 0)
+	admin := &MockKafkaAdmin{}
+	admin.ElectLeadersFn = func(electionType ElectionType, topicPartitions map[string][]int32) (map[string]map[int32]sarama.KError, error) {
+		assert.Equal(t, PreferredElection, electionType)
+		batches = append(batches, topicPartitions)
+		return nil, nil
+	}
+	c := NewClient(&MockHttpClient{}, &mk, admin)
+
+	partitions, err := c.PartitionsWithoutPreferredLeader()
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string][]int32{"topic-1": {2}, "topic-2": {1}}, partitions)
+	}
+
+	if assert.NoError(t, c.ElectPreferredLeaders(1)) {
+		assert.Equal(t, []map[string][]int32{{"topic-1": {2}}, {"topic-2": {1}}}, batches)
+	}
+
+	batches = batches[:0]
+	if assert.NoError(t, c.ElectPreferredLeaders(0)) {
+		assert.Equal(t, []map[string][]int32{{"topic-1": {2}, "topic-2": {1}}}, batches)
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 
-	confluent "github.com/wayarmy/gonfluent"
+	confluent "github.com/OneMount/gonfluent"
 )
 
 const (
@@ -19,8 +19,6 @@ func main() {
 	password := os.Getenv("CONFLUENT_PASSWORD")
 
 	// Initialize the client
-	httpClient := confluent.NewDefaultHttpClient(baseUrl, username, password)
-	httpClient.UserAgent = UserAgent
 	bootstrapServer := []string{
 		"localhost:9093",
 	}
@@ -31,20 +29,22 @@ func main() {
 		ClientCertKey:    "certs/key.pem",
 		SkipTLSVerify:    true,
 		SASLMechanism:    "plain",
+		SASLUsername:     username,
+		SASLPassword:     password,
 		TLSEnabled:       true,
 		Timeout:          120,
 	}
-	kClient, err := confluent.NewSaramaClient(kConfig)
+	client, err := confluent.New(
+		confluent.WithBaseUrl(baseUrl),
+		confluent.WithCredentials(username, password),
+		confluent.WithUserAgent(UserAgent),
+		confluent.WithKafkaConfig(kConfig),
+		confluent.WithLogin(),
+	)
 	if err != nil {
 		panic(err)
 	}
-
-	client := confluent.NewClient(httpClient, kClient)
-	bearerToken, err := client.Login()
-	if err != nil {
-		panic(err)
-	}
-	httpClient.Token = bearerToken
+	defer client.Close()
 
 	// Get the list of clusters in Confluent platform
 	listCluster, err := client.ListKafkaCluster()
@@ -3,6 +3,12 @@ module github.com/OneMount/gonfluent
 go 1.16
 
 require (
-	github.com/Shopify/sarama v1.29.1
+	github.com/Shopify/sarama v1.30.0
+	github.com/pavel-v-chernykh/keystore-go/v4 v4.1.0
 	github.com/stretchr/testify v1.7.0
+	github.com/xdg/scram v1.0.3
+	github.com/xdg/stringprep v1.0.3 // indirect
+	golang.org/x/crypto v0.0.0-20210920023735-84f357641f63
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
+	software.sslmate.com/src/go-pkcs12 v0.0.0-20210415151418-c5206de65a78
 )
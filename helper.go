@@ -13,13 +13,47 @@ import (
 	"github.com/Shopify/sarama"
 )
 
+const (
+	defaultKerberosServiceName = "kafka"
+	defaultKerberosConfigPath  = "/etc/krb5.conf"
+
+	kafkaVersionAuto = "auto"
+)
+
+var defaultKafkaVersion = sarama.V2_4_0_0
+
 func (co *Config) saslEnabled() bool {
-	return co.SASLUsername != "" || co.SASLPassword != ""
+	return co.SASLUsername != "" || co.SASLPassword != "" || co.SASLTokenProvider != nil
+}
+
+func (co *Config) autoDetectKafkaVersion() bool {
+	return co.KafkaVersion == kafkaVersionAuto
+}
+
+// kafkaVersion returns the configured Kafka version. When it is detected, the lowest version able to
+// request the supported API versions is used until the brokers have been asked.
+func (co *Config) kafkaVersion() (sarama.KafkaVersion, error) {
+	switch co.KafkaVersion {
+	case "":
+		return defaultKafkaVersion, nil
+	case kafkaVersionAuto:
+		return kafkaVersionProbes[0].version, nil
+	}
+	version, err := sarama.ParseKafkaVersion(co.KafkaVersion)
+	if err != nil {
+		return version, fmt.Errorf("[ERROR] Invalid kafka version \"%s\": %w", co.KafkaVersion, err)
+	}
+	return version, nil
 }
 
 func (co *Config) newKafkaConfig() (*sarama.Config, error) {
+	version, err := co.kafkaVersion()
+	if err != nil {
+		return nil, err
+	}
+
 	kafkaConfig := sarama.NewConfig()
-	kafkaConfig.Version = sarama.V2_4_0_0
+	kafkaConfig.Version = version
 	kafkaConfig.ClientID = "confluent-go-client"
 	kafkaConfig.Admin.Timeout = time.Duration(co.Timeout) * time.Second
 	kafkaConfig.Metadata.Full = true // the default, but just being clear
@@ -27,10 +61,30 @@ func (co *Config) newKafkaConfig() (*sarama.Config, error) {
 	if co.saslEnabled() {
 		switch co.SASLMechanism {
 		case "scram-sha512":
+			kafkaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+			kafkaConfig.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(scramSHA512)
+			kafkaConfig.Net.SASL.Version = sarama.SASLHandshakeV1
 		case "scram-sha256":
+			kafkaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+			kafkaConfig.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(scramSHA256)
+			kafkaConfig.Net.SASL.Version = sarama.SASLHandshakeV1
 		case "plain":
+			kafkaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		case "oauthbearer":
+			if co.SASLTokenProvider == nil {
+				return nil, errors.New("[ERROR] No token provider provided for sasl mechanism \"oauthbearer\"")
+			}
+			kafkaConfig.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+			kafkaConfig.Net.SASL.TokenProvider = co.SASLTokenProvider
+		case "gssapi":
+			gssapiConfig, err := co.newGSSAPIConfig()
+			if err != nil {
+				return nil, err
+			}
+			kafkaConfig.Net.SASL.Mechanism = sarama.SASLTypeGSSAPI
+			kafkaConfig.Net.SASL.GSSAPI = *gssapiConfig
 		default:
-			return nil, errors.New("[ERROR] Invalid sasl mechanism \"%s\": can only be \"scram-sha256\", \"scram-sha512\" or \"plain\"" + co.SASLMechanism)
+			return nil, fmt.Errorf("[ERROR] Invalid sasl mechanism \"%s\": can only be \"scram-sha256\", \"scram-sha512\", \"plain\", \"oauthbearer\" or \"gssapi\"", co.SASLMechanism)
 		}
 		kafkaConfig.Net.SASL.Enable = true
 		kafkaConfig.Net.SASL.Password = co.SASLPassword
@@ -41,24 +95,99 @@ func (co *Config) newKafkaConfig() (*sarama.Config, error) {
 	}
 
 	if co.TLSEnabled {
-		tlsConfig, err := newTLSConfig(
-			co.ClientCert,
-			co.ClientCertKey,
-			co.CACert,
-			co.ClientCertKeyPassphrase,
-		)
-
+		tlsConfig, err := co.NewTLSConfig()
 		if err != nil {
 			return kafkaConfig, err
 		}
 		kafkaConfig.Net.TLS.Enable = true
 		kafkaConfig.Net.TLS.Config = tlsConfig
-		kafkaConfig.Net.TLS.Config.InsecureSkipVerify = co.SkipTLSVerify
 	}
 
 	return kafkaConfig, nil
 }
 
+// closeCertReloader stops the certificate reloader created by NewTLSConfig, a new one is created by the next call
+func (co *Config) closeCertReloader() {
+	if co.certReloader != nil {
+		co.certReloader.Close()
+		co.certReloader = nil
+	}
+}
+
+// NewTLSConfig returns the TLS config used to connect to the brokers, it can be shared with the REST client
+// through DefaultHttpClient.TLSConfig
+func (co *Config) NewTLSConfig() (*tls.Config, error) {
+	m, err := co.tlsMaterial()
+	if err != nil {
+		return nil, err
+	}
+
+	if co.CertReloadInterval > 0 {
+		if co.certReloader == nil {
+			reloader, err := NewCertificateReloader(
+				m.clientCert,
+				m.clientKey,
+				m.caCert,
+				m.clientKeyPassphrase,
+				time.Duration(co.CertReloadInterval)*time.Second,
+				co.OnCertRotation,
+			)
+			if err != nil {
+				return nil, err
+			}
+			co.certReloader = reloader
+		}
+		return co.certReloader.TLSConfig(co.SkipTLSVerify), nil
+	}
+
+	tlsConfig, err := newTLSConfig(
+		m.clientCert,
+		m.clientKey,
+		m.caCert,
+		m.clientKeyPassphrase,
+	)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.InsecureSkipVerify = co.SkipTLSVerify
+	return tlsConfig, nil
+}
+
+func (co *Config) newGSSAPIConfig() (*sarama.GSSAPIConfig, error) {
+	if co.SASLUsername == "" {
+		return nil, errors.New("[ERROR] No kerberos principal provided for sasl mechanism \"gssapi\"")
+	}
+	if co.KerberosRealm == "" {
+		return nil, errors.New("[ERROR] No kerberos realm provided for sasl mechanism \"gssapi\"")
+	}
+
+	gssapiConfig := sarama.GSSAPIConfig{
+		ServiceName:        co.KerberosServiceName,
+		KerberosConfigPath: co.KerberosConfigPath,
+		Username:           co.SASLUsername,
+		Realm:              co.KerberosRealm,
+		DisablePAFXFAST:    co.KerberosDisablePAFXFAST,
+	}
+	if gssapiConfig.ServiceName == "" {
+		gssapiConfig.ServiceName = defaultKerberosServiceName
+	}
+	if gssapiConfig.KerberosConfigPath == "" {
+		gssapiConfig.KerberosConfigPath = defaultKerberosConfigPath
+	}
+
+	switch {
+	case co.KerberosKeytabPath != "":
+		gssapiConfig.AuthType = sarama.KRB5_KEYTAB_AUTH
+		gssapiConfig.KeyTabPath = co.KerberosKeytabPath
+	case co.SASLPassword != "":
+		gssapiConfig.AuthType = sarama.KRB5_USER_AUTH
+		gssapiConfig.Password = co.SASLPassword
+	default:
+		return nil, errors.New("[ERROR] No keytab nor password provided for sasl mechanism \"gssapi\"")
+	}
+	return &gssapiConfig, nil
+}
+
 //func NewTLSConfig(clientCert, clientKey, caCert, clientKeyPassphrase string) (*tls.Config, error) {
 //	return newTLSConfig(clientCert, clientKey, caCert, clientKeyPassphrase)
 //}
@@ -87,18 +216,12 @@ func parsePemOrLoadFromFile(input string) (*pem.Block, []byte, error) {
 func newTLSConfig(clientCert, clientKey, caCert, clientKeyPassphrase string) (*tls.Config, error) {
 	tlsConfig := tls.Config{}
 
-	if clientCert != "" && clientKey != "" {
-		keyBlock, keyBytes, err := parsePemOrLoadFromFile(clientKey)
-		if err != nil {
-			return &tlsConfig, err
-		}
-
-		keyBytes = pem.EncodeToMemory(&pem.Block{
-			Type:  keyBlock.Type,
-			Bytes: keyBytes,
-		})
+	if (clientCert == "") != (clientKey == "") {
+		return &tlsConfig, errors.New("[ERROR] Both the client certificate and the client key must be provided")
+	}
 
-		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if clientCert != "" && clientKey != "" {
+		cert, err := loadX509KeyPair(clientCert, clientKey, clientKeyPassphrase)
 		if err != nil {
 			return &tlsConfig, err
 		}
@@ -109,6 +232,17 @@ func newTLSConfig(clientCert, clientKey, caCert, clientKeyPassphrase string) (*t
 		return &tlsConfig, nil
 	}
 
+	caCertPool, err := loadCertPool(caCert)
+	if err != nil {
+		return &tlsConfig, err
+	}
+
+	tlsConfig.RootCAs = caCertPool
+	return &tlsConfig, nil
+}
+
+// loadCertPool adds the CA certificates, given inline as PEM or as the path of a PEM file, to the system pool
+func loadCertPool(caCert string) (*x509.CertPool, error) {
 	caCertPool, _ := x509.SystemCertPool()
 	if caCertPool == nil {
 		caCertPool = x509.NewCertPool()
@@ -116,30 +250,22 @@ func newTLSConfig(clientCert, clientKey, caCert, clientKeyPassphrase string) (*t
 
 	_, caBytes, err := parsePemOrLoadFromFile(caCert)
 	if err != nil {
-		return &tlsConfig, err
+		return nil, err
 	}
 	ok := caCertPool.AppendCertsFromPEM(caBytes)
 	if !ok {
-		return &tlsConfig, errors.New("couldn't add the caPem")
+		return nil, errors.New("couldn't add the caPem")
 	}
-
-	tlsConfig.RootCAs = caCertPool
-	return &tlsConfig, nil
+	return caCertPool, nil
 }
 
 func (co *Config) copyWithMaskedSensitiveValues() Config {
-	c := Config{
-		co.BootstrapServers,
-		co.Timeout,
-		co.CACert,
-		co.ClientCert,
-		"*****",
-		"*****",
-		co.TLSEnabled,
-		co.SkipTLSVerify,
-		co.SASLUsername,
-		"*****",
-		co.SASLMechanism,
-	}
+	c := *co
+	c.ClientCertKey = "*****"
+	c.ClientCertKeyPassphrase = "*****"
+	c.SASLPassword = "*****"
+	c.KeystorePassword = "*****"
+	c.KeyPassword = "*****"
+	c.TruststorePassword = "*****"
 	return c
 }
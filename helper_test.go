@@ -0,0 +1,238 @@
+package confluent
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/xdg/scram"
+)
+
+const (
+	scramTestClientNonce = "fyko+d2lbbFgONRv9qkxdawL"
+	scramTestServerNonce = "3rfcNHYJY1ZVvWVs7j"
+)
+
+// scramTestServerMessages runs the whole exchange against a real SCRAM server and returns
+// the server-first and server-final messages the mock broker has to answer with
+func scramTestServerMessages(t *testing.T, hashGenerator scram.HashGeneratorFcn, user, password string) []string {
+	kf := scram.KeyFactors{Salt: "QSXCR+Q6sek8bf92", Iters: 4096}
+	storedClient, err := hashGenerator.NewClient(user, password, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := storedClient.GetStoredCredentials(kf)
+	server, err := hashGenerator.NewServer(func(string) (scram.StoredCredentials, error) {
+		return stored, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConv := server.WithNonceGenerator(func() string { return scramTestServerNonce }).NewConversation()
+
+	client := &scramClient{HashGeneratorFcn: hashGenerator, nonceGenerator: func() string { return scramTestClientNonce }}
+	if err := client.Begin(user, password, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := make([]string, 0, 2)
+	challenge := ""
+	for !client.Done() {
+		msg, err := client.Step(challenge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if client.Done() {
+			break
+		}
+		challenge, err = serverConv.Step(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, challenge)
+	}
+	return messages
+}
+
+func newScramTestBroker(t *testing.T, mechanism sarama.SASLMechanism, serverMessages []string) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	authResponses := make([]interface{}, 0, len(serverMessages))
+	for _, m := range serverMessages {
+		authResponses = append(authResponses, sarama.NewMockSaslAuthenticateResponse(t).SetAuthBytes([]byte(m)))
+	}
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest":      sarama.NewMockApiVersionsResponse(t),
+		"SaslHandshakeRequest":    sarama.NewMockSaslHandshakeResponse(t).SetEnabledMechanisms([]string{string(mechanism)}),
+		"SaslAuthenticateRequest": sarama.NewMockSequence(authResponses...),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()),
+	})
+	return broker
+}
+
+func TestHelper_NewKafkaConfigSASLMechanisms(t *testing.T) {
+	for mechanism, expected := range map[string]sarama.SASLMechanism{
+		"plain":        sarama.SASLTypePlaintext,
+		"scram-sha256": sarama.SASLTypeSCRAMSHA256,
+		"scram-sha512": sarama.SASLTypeSCRAMSHA512,
+	} {
+		config := Config{Timeout: 10, SASLUsername: "alice", SASLPassword: "secret", SASLMechanism: mechanism}
+		kc, err := config.newKafkaConfig()
+		if assert.NoError(t, err) {
+			assert.True(t, kc.Net.SASL.Enable)
+			assert.Equal(t, expected, kc.Net.SASL.Mechanism)
+			assert.NoError(t, kc.Validate())
+		}
+	}
+
+	config := Config{Timeout: 10, SASLUsername: "alice", SASLPassword: "secret", SASLMechanism: "md5"}
+	_, err := config.newKafkaConfig()
+	assert.EqualError(t, err, "[ERROR] Invalid sasl mechanism \"md5\": can only be \"scram-sha256\", \"scram-sha512\", \"plain\", \"oauthbearer\" or \"gssapi\"")
+}
+
+func TestHelper_NewKafkaConfigOAuthBearer(t *testing.T) {
+	provider := NewMDSTokenProvider(&MockHttpClient{})
+	config := Config{Timeout: 10, SASLMechanism: "oauthbearer", SASLTokenProvider: provider}
+	kc, err := config.newKafkaConfig()
+	if assert.NoError(t, err) {
+		assert.True(t, kc.Net.SASL.Enable)
+		assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeOAuth), kc.Net.SASL.Mechanism)
+		assert.Equal(t, provider, kc.Net.SASL.TokenProvider)
+		assert.NoError(t, kc.Validate())
+	}
+
+	config = Config{Timeout: 10, SASLUsername: "alice", SASLMechanism: "oauthbearer"}
+	_, err = config.newKafkaConfig()
+	assert.EqualError(t, err, "[ERROR] No token provider provided for sasl mechanism \"oauthbearer\"")
+}
+
+func TestHelper_NewKafkaConfigGSSAPI(t *testing.T) {
+	config := Config{Timeout: 10, SASLUsername: "kafka-client", SASLMechanism: "gssapi", KerberosRealm: "EXAMPLE.COM", KerberosKeytabPath: "/etc/security/kafka.keytab"}
+	kc, err := config.newKafkaConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeGSSAPI), kc.Net.SASL.Mechanism)
+		assert.Equal(t, sarama.GSSAPIConfig{
+			AuthType:           sarama.KRB5_KEYTAB_AUTH,
+			KeyTabPath:         "/etc/security/kafka.keytab",
+			KerberosConfigPath: "/etc/krb5.conf",
+			ServiceName:        "kafka",
+			Username:           "kafka-client",
+			Realm:              "EXAMPLE.COM",
+		}, kc.Net.SASL.GSSAPI)
+		assert.NoError(t, kc.Validate())
+	}
+
+	config = Config{Timeout: 10, SASLUsername: "kafka-client", SASLPassword: "secret", SASLMechanism: "gssapi", KerberosRealm: "EXAMPLE.COM", KerberosServiceName: "broker"}
+	kc, err = config.newKafkaConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, sarama.KRB5_USER_AUTH, kc.Net.SASL.GSSAPI.AuthType)
+		assert.Equal(t, "secret", kc.Net.SASL.GSSAPI.Password)
+		assert.Equal(t, "broker", kc.Net.SASL.GSSAPI.ServiceName)
+	}
+
+	config = Config{Timeout: 10, SASLUsername: "kafka-client", SASLMechanism: "gssapi", KerberosRealm: "EXAMPLE.COM"}
+	_, err = config.newKafkaConfig()
+	assert.EqualError(t, err, "[ERROR] No keytab nor password provided for sasl mechanism \"gssapi\"")
+
+	config = Config{Timeout: 10, SASLUsername: "kafka-client", SASLPassword: "secret", SASLMechanism: "gssapi"}
+	_, err = config.newKafkaConfig()
+	assert.EqualError(t, err, "[ERROR] No kerberos realm provided for sasl mechanism \"gssapi\"")
+}
+
+func TestHelper_CopyWithMaskedSensitiveValues(t *testing.T) {
+	config := Config{
+		SASLUsername: "alice", SASLPassword: "secret", ClientCertKey: "key.pem", KerberosRealm: "EXAMPLE.COM",
+		KeystoreLocation: "client.p12", KeystorePassword: "keystore-secret", KeyPassword: "key-secret",
+		TruststoreLocation: "truststore.jks", TruststorePassword: "truststore-secret",
+	}
+	masked := config.copyWithMaskedSensitiveValues()
+	assert.Equal(t, "*****", masked.SASLPassword)
+	assert.Equal(t, "*****", masked.ClientCertKey)
+	assert.Equal(t, "*****", masked.KeystorePassword)
+	assert.Equal(t, "*****", masked.KeyPassword)
+	assert.Equal(t, "*****", masked.TruststorePassword)
+	assert.Equal(t, "client.p12", masked.KeystoreLocation)
+	assert.Equal(t, "truststore.jks", masked.TruststoreLocation)
+	assert.Equal(t, "alice", masked.SASLUsername)
+	assert.Equal(t, "EXAMPLE.COM", masked.KerberosRealm)
+	assert.Equal(t, "secret", config.SASLPassword)
+}
+
+func TestHelper_ScramAuthenticationAgainstMockBroker(t *testing.T) {
+	for mechanism, hashGenerator := range map[string]scram.HashGeneratorFcn{
+		"scram-sha256": scramSHA256,
+		"scram-sha512": scramSHA512,
+	} {
+		config := Config{Timeout: 10, SASLUsername: "alice", SASLPassword: "secret", SASLMechanism: mechanism}
+		kc, err := config.newKafkaConfig()
+		if !assert.NoError(t, err) {
+			continue
+		}
+		generate := kc.Net.SASL.SCRAMClientGeneratorFunc
+		kc.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			c := generate().(*scramClient)
+			c.nonceGenerator = func() string { return scramTestClientNonce }
+			return c
+		}
+
+		broker := newScramTestBroker(t, kc.Net.SASL.Mechanism, scramTestServerMessages(t, hashGenerator, "alice", "secret"))
+		b := sarama.NewBroker(broker.Addr())
+		if assert.NoError(t, b.Open(kc)) {
+			_, err = b.GetMetadata(&sarama.MetadataRequest{})
+			assert.NoError(t, err, mechanism)
+			_ = b.Close()
+		}
+		broker.Close()
+	}
+}
+
+func TestHelper_ScramAuthenticationWrongPassword(t *testing.T) {
+	config := Config{Timeout: 10, SASLUsername: "alice", SASLPassword: "wrong", SASLMechanism: "scram-sha512"}
+	kc, err := config.newKafkaConfig()
+	if !assert.NoError(t, err) {
+		return
+	}
+	generate := kc.Net.SASL.SCRAMClientGeneratorFunc
+	kc.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+		c := generate().(*scramClient)
+		c.nonceGenerator = func() string { return scramTestClientNonce }
+		return c
+	}
+
+	broker := newScramTestBroker(t, kc.Net.SASL.Mechanism, scramTestServerMessages(t, scramSHA512, "alice", "secret"))
+	defer broker.Close()
+	b := sarama.NewBroker(broker.Addr())
+	if assert.NoError(t, b.Open(kc)) {
+		_, err = b.GetMetadata(&sarama.MetadataRequest{})
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "server validation failed")
+		}
+		_ = b.Close()
+	}
+}
+
+func TestHelper_NewKafkaConfigVersion(t *testing.T) {
+	config := Config{Timeout: 10}
+	kc, err := config.newKafkaConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, sarama.V2_4_0_0, kc.Version)
+		assert.False(t, config.autoDetectKafkaVersion())
+	}
+
+	config.KafkaVersion = "2.8.0"
+	kc, err = config.newKafkaConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, sarama.V2_8_0_0, kc.Version)
+	}
+
+	config.KafkaVersion = "auto"
+	kc, err = config.newKafkaConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, sarama.V0_10_0_0, kc.Version)
+	}
+
+	config.KafkaVersion = "latest"
+	_, err = config.newKafkaConfig()
+	assert.NotNil(t, err)
+}
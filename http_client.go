@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
 )
 
 type HttpClient interface {
@@ -23,6 +24,13 @@ type DefaultHttpClient struct {
 	Password  string
 	Token     string
 	UserAgent string
+
+	// TLSConfig is used to connect to the API when set, e.g. CertificateReloader.TLSConfig.
+	// The server certificate is not verified otherwise. It is read by the first request.
+	TLSConfig *tls.Config
+
+	clientOnce sync.Once
+	client     *http.Client
 }
 func NewDefaultHttpClient(baseUrl string, username string, password string) *DefaultHttpClient {
 	return &DefaultHttpClient{
@@ -33,11 +41,7 @@ func NewDefaultHttpClient(baseUrl string, username string, password string) *Def
 	}
 }
 func (c *DefaultHttpClient) DoRequest(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
-	client := http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
-	}
+	client := c.httpClient()
 	req, err := http.NewRequest(method, c.BaseUrl+uri, reqBody)
 	if err != nil {
 		return nil, 0, "", err
@@ -59,6 +63,41 @@ func (c *DefaultHttpClient) DoRequest(method string, uri string, reqBody io.Read
 		return nil, 0, "", respErr
 	}
 
+	defer res.Body.Close()
+
 	respBody, bodyErr := ioutil.ReadAll(res.Body)
 	return respBody, res.StatusCode, res.Status, bodyErr
 }
+
+// withBasicAuth returns a client of the same API which authenticates with the username and password even
+// once the Token is set
+func (c *DefaultHttpClient) withBasicAuth() *DefaultHttpClient {
+	return &DefaultHttpClient{
+		BaseUrl:   c.BaseUrl,
+		Username:  c.Username,
+		Password:  c.Password,
+		UserAgent: c.UserAgent,
+		TLSConfig: c.TLSConfig,
+	}
+}
+
+// httpClient returns the client shared by all requests, so that connections are reused
+func (c *DefaultHttpClient) httpClient() *http.Client {
+	c.clientOnce.Do(func() {
+		tlsConfig := c.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		c.client = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+		}
+	})
+	return c.client
+}
+
+// CloseIdleConnections closes the connections kept alive for the next requests
+func (c *DefaultHttpClient) CloseIdleConnections() {
+	c.httpClient().CloseIdleConnections()
+}
@@ -18,6 +18,43 @@ type Config struct {
 	SASLUsername            string
 	SASLPassword            string
 	SASLMechanism           string
+
+	// KafkaVersion is the Kafka protocol version used with the brokers, e.g. "2.8.0". It defaults to "2.4.0",
+	// "auto" selects the highest version supported by all brokers of the cluster.
+	KafkaVersion string
+
+	// SASLTokenProvider provides the tokens when SASLMechanism is "oauthbearer", see NewMDSTokenProvider
+	SASLTokenProvider sarama.AccessTokenProvider
+
+	// Kerberos settings when SASLMechanism is "gssapi": SASLUsername is the Kerberos principal,
+	// authenticated with the keytab when KerberosKeytabPath is set, with SASLPassword otherwise. A Kerberos
+	// credentials cache is not supported, the ticket is always obtained from the keytab or the password.
+	// KerberosServiceName defaults to "kafka" and KerberosConfigPath to "/etc/krb5.conf".
+	// ElectLeaders and AlterReplicaLogDirs, which are sent without sarama, do not support "gssapi".
+	KerberosServiceName     string
+	KerberosRealm           string
+	KerberosConfigPath      string
+	KerberosKeytabPath      string
+	KerberosDisablePAFXFAST bool
+
+	// Keystore with the client certificate chain and key, and truststore with the CA certificates, as used by
+	// the Java clients, instead of ClientCert, ClientCertKey and CACert. Types are "JKS", "PKCS12" or "PEM" and
+	// are guessed from the file extension when empty. KeyPassword defaults to KeystorePassword.
+	// JKS and PKCS12 stores are read once, only PEM stores are reloaded with CertReloadInterval.
+	KeystoreLocation   string
+	KeystoreType       string
+	KeystorePassword   string
+	KeyPassword        string
+	TruststoreLocation string
+	TruststoreType     string
+	TruststorePassword string
+
+	// CertReloadInterval is the number of seconds between two checks of the CACert, ClientCert and
+	// ClientCertKey files, 0 disables reloading. OnCertRotation is called after each check that found changes.
+	CertReloadInterval int
+	OnCertRotation     func(CertificateRotationEvent)
+
+	certReloader *CertificateReloader
 }
 
 type void struct{}
@@ -28,7 +65,7 @@ type DefaultSaramaClient struct {
 	client        sarama.Client
 	kafkaConfig   *sarama.Config
 	config        *Config
-	supportedAPIs map[int]int
+	supportedAPIs map[int][2]int // min and max versions supported by all brokers, by api key
 	topics        map[string]void
 }
 
@@ -45,7 +82,7 @@ func (k *DefaultSaramaClient) extractTopics() error {
 }
 
 func (k *DefaultSaramaClient) populateAPIVersions() error {
-	ch := make(chan []*sarama.ApiVersionsResponseBlock)
+	ch := make(chan []sarama.ApiVersionsResponseKey)
 	errCh := make(chan error)
 
 	brokers := k.client.Brokers()
@@ -54,12 +91,12 @@ func (k *DefaultSaramaClient) populateAPIVersions() error {
 		go apiVersionsFromBroker(broker, kafkaConfig, ch, errCh)
 	}
 
-	clusterApiVersions := make(map[int][2]int) // valid api version intervals across all brokers
+	brokersApiVersions := make([][]sarama.ApiVersionsResponseKey, 0, len(brokers))
 	errs := make([]error, 0)
 	for i := 0; i < len(brokers); i++ {
 		select {
 		case brokerApiVersions := <-ch:
-			updateClusterApiVersions(&clusterApiVersions, brokerApiVersions)
+			brokersApiVersions = append(brokersApiVersions, brokerApiVersions)
 		case err := <-errCh:
 			errs = append(errs, err)
 		}
@@ -69,20 +106,81 @@ func (k *DefaultSaramaClient) populateAPIVersions() error {
 		return errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
 	}
 
-	k.supportedAPIs = make(map[int]int, len(clusterApiVersions))
+	clusterApiVersions := intersectApiVersions(brokersApiVersions) // valid api version intervals across all brokers
+	k.supportedAPIs = make(map[int][2]int, len(clusterApiVersions))
 	for apiKey, versionMinMax := range clusterApiVersions {
 		versionMin := versionMinMax[0]
 		versionMax := versionMinMax[1]
 
 		if versionMax >= versionMin {
-			k.supportedAPIs[apiKey] = versionMax
+			k.supportedAPIs[apiKey] = versionMinMax
 		}
 	}
 
 	return nil
 }
 
-func updateClusterApiVersions(clusterApiVersions *map[int][2]int, brokerApiVersions []*sarama.ApiVersionsResponseBlock) {
+// kafkaVersionProbes lists for each Kafka version an API version it introduced, in ascending order
+var kafkaVersionProbes = []struct {
+	version    sarama.KafkaVersion
+	apiKey     int
+	apiVersion int
+}{
+	{sarama.V0_10_0_0, 18, 0}, // ApiVersions
+	{sarama.V0_10_1_0, 19, 0}, // CreateTopics
+	{sarama.V0_10_2_0, 2, 1},  // ListOffsets v1
+	{sarama.V0_11_0_0, 32, 0}, // DescribeConfigs
+	{sarama.V1_0_0_0, 37, 0},  // CreatePartitions
+	{sarama.V1_1_0_0, 42, 0},  // DeleteGroups
+	{sarama.V2_0_0_0, 18, 2},  // ApiVersions v2
+	{sarama.V2_1_0_0, 0, 7},   // Produce v7
+	{sarama.V2_2_0_0, 43, 0},  // ElectLeaders
+	{sarama.V2_3_0_0, 44, 0},  // IncrementalAlterConfigs
+	{sarama.V2_4_0_0, 45, 0},  // AlterPartitionReassignments
+	{sarama.V2_5_0_0, 14, 5},  // SyncGroup v5
+	{sarama.V2_6_0_0, 48, 0},  // DescribeClientQuotas
+	{sarama.V2_7_0_0, 50, 0},  // DescribeUserScramCredentials
+	{sarama.V2_8_0_0, 60, 0},  // DescribeCluster
+}
+
+// detectKafkaVersion returns the highest Kafka version whose APIs are all supported by the cluster
+func detectKafkaVersion(supportedAPIs map[int][2]int) sarama.KafkaVersion {
+	version := kafkaVersionProbes[0].version
+	for _, probe := range kafkaVersionProbes {
+		if !supportsAPI(supportedAPIs, probe.apiKey, probe.apiVersion) {
+			break
+		}
+		version = probe.version
+	}
+	return version
+}
+
+func supportsAPI(supportedAPIs map[int][2]int, apiKey int, version int) bool {
+	versionMinMax, ok := supportedAPIs[apiKey]
+	return ok && version >= versionMinMax[0] && version <= versionMinMax[1]
+}
+
+// intersectApiVersions returns the version intervals of the APIs supported by all the brokers
+func intersectApiVersions(brokersApiVersions [][]sarama.ApiVersionsResponseKey) map[int][2]int {
+	clusterApiVersions := make(map[int][2]int)
+	brokerCounts := make(map[int]int)
+	for _, brokerApiVersions := range brokersApiVersions {
+		updateClusterApiVersions(&clusterApiVersions, brokerApiVersions)
+		for _, apiBlock := range brokerApiVersions {
+			brokerCounts[int(apiBlock.ApiKey)]++
+		}
+	}
+
+	// An API unknown to one of the brokers is not supported by the cluster
+	for apiKey, count := range brokerCounts {
+		if count < len(brokersApiVersions) {
+			delete(clusterApiVersions, apiKey)
+		}
+	}
+	return clusterApiVersions
+}
+
+func updateClusterApiVersions(clusterApiVersions *map[int][2]int, brokerApiVersions []sarama.ApiVersionsResponseKey) {
 	cluster := *clusterApiVersions
 
 	for _, apiBlock := range brokerApiVersions {
@@ -113,15 +211,15 @@ func updateClusterApiVersions(clusterApiVersions *map[int][2]int, brokerApiVersi
 	}
 }
 
-func apiVersionsFromBroker(broker *sarama.Broker, config *sarama.Config, ch chan<- []*sarama.ApiVersionsResponseBlock, errCh chan<- error) {
+func apiVersionsFromBroker(broker *sarama.Broker, config *sarama.Config, ch chan<- []sarama.ApiVersionsResponseKey, errCh chan<- error) {
 	resp, err := rawApiVersionsRequest(broker, config)
 
 	if err != nil {
 		errCh <- err
-	} else if resp.Err != sarama.ErrNoError {
-		errCh <- errors.New(resp.Err.Error())
+	} else if err := sarama.KError(resp.ErrorCode); err != sarama.ErrNoError {
+		errCh <- errors.New(err.Error())
 	} else {
-		ch <- resp.ApiVersions
+		ch <- resp.ApiKeys
 	}
 }
 
@@ -2,26 +2,80 @@ package confluent
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+
 	"github.com/Shopify/sarama"
 )
 
+const (
+	apiKeyDeleteRecords                = 21
+	apiKeyAlterReplicaLogDirs          = 34
+	apiKeyDescribeLogDirs              = 35
+	apiKeyElectLeaders                 = 43
+	apiKeyAlterPartitionReassignments  = 45
+	apiKeyListPartitionReassignments   = 46
+	apiKeyDescribeClientQuotas         = 48
+	apiKeyAlterClientQuotas            = 49
+	apiKeyDescribeUserScramCredentials = 50
+	apiKeyAlterUserScramCredentials    = 51
+)
+
+// apiKafkaVersions are the Kafka versions the client must be configured with to send each version of the APIs, sarama
+// refuses to send the requests of a later Kafka version. The requests sent on a brokerConn are not restricted.
+var apiKafkaVersions = map[int][]sarama.KafkaVersion{
+	apiKeyDeleteRecords:                {sarama.V0_11_0_0},
+	apiKeyDescribeLogDirs:              {sarama.V1_0_0_0},
+	apiKeyAlterPartitionReassignments:  {sarama.V2_4_0_0},
+	apiKeyListPartitionReassignments:   {sarama.V2_4_0_0},
+	apiKeyDescribeClientQuotas:         {sarama.V2_6_0_0},
+	apiKeyAlterClientQuotas:            {sarama.V2_6_0_0},
+	apiKeyDescribeUserScramCredentials: {sarama.V2_7_0_0},
+	apiKeyAlterUserScramCredentials:    {sarama.V2_7_0_0},
+}
+
 type SaramaClusterAdmin interface {
 	ListPartitionReassignments(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error)
 	AlterPartitionReassignments(topic string, assignment [][]int32) error
+	DeleteRecords(topic string, partitionOffsets map[int32]int64) error
+	// ElectLeaders returns the error of the election of each partition
+	ElectLeaders(electionType ElectionType, topicPartitions map[string][]int32) (map[string]map[int32]sarama.KError, error)
+	DescribeLogDirs(brokerIds []int32) (map[int32][]sarama.DescribeLogDirsResponseDirMetadata, error)
+	// AlterReplicaLogDirs moves the replicas of the broker to the log directories, given by path, topic and
+	// partitions, and returns the error of each replica
+	AlterReplicaLogDirs(brokerId int32, logDirs map[string]map[string][]int32) (map[string]map[int32]sarama.KError, error)
+	DescribeClientQuotas(filter ClientQuotaFilter) ([]ClientQuota, error)
+	// AlterClientQuotas sets and removes quotas of the entity
+	AlterClientQuotas(entity ClientQuotaEntity, set map[QuotaKey]float64, remove []QuotaKey, validateOnly bool) error
+	DescribeUserScramCredentials(users []string) ([]*sarama.DescribeUserScramCredentialsResult, error)
+	UpsertUserScramCredentials(upsert []sarama.AlterUserScramCredentialsUpsert) ([]*sarama.AlterUserScramCredentialsResult, error)
+	DeleteUserScramCredentials(delete []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error)
 }
 
 type SaramaClient interface {
 	Controller() (*sarama.Broker, error)
 	Config() *sarama.Config
 	RefreshMetadata() error
+	Topics() ([]string, error)
 	Partitions(topic string) ([]int32, error)
 	Brokers() []*sarama.Broker
 	Replicas(topic string, partitionId int32) ([]int32, error)
+	InSyncReplicas(topic string, partitionId int32) ([]int32, error)
+	Leader(topic string, partitionId int32) (*sarama.Broker, error)
 	ID(broker *sarama.Broker) int32
+	Rack(broker *sarama.Broker) string
+	SupportsAPI(apiKey int, version int) bool
+	// GetOffset returns the offset of the first record at or after the time in milliseconds, or the oldest or newest
+	// offset with sarama.OffsetOldest and sarama.OffsetNewest
+	GetOffset(topic string, partitionId int32, time int64) (int64, error)
+	// NewConsumer returns a consumer sharing the connections of the client, it must be closed
+	NewConsumer() (sarama.Consumer, error)
 }
 
 type DefaultSaramaClusterAdmin struct {
 	adminClient         sarama.ClusterAdmin
+	// client connects to the brokers for the requests the admin client does not implement
+	client sarama.Client
 }
 
 func (ca *DefaultSaramaClusterAdmin) ListPartitionReassignments(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
@@ -32,10 +86,166 @@ func (ca *DefaultSaramaClusterAdmin) AlterPartitionReassignments(topic string, a
 	return ca.adminClient.AlterPartitionReassignments(topic, assignment)
 }
 
+func (ca *DefaultSaramaClusterAdmin) DeleteRecords(topic string, partitionOffsets map[int32]int64) error {
+	return ca.adminClient.DeleteRecords(topic, partitionOffsets)
+}
+
+// ElectLeaders sends the ElectLeaders request to the controller, v0 for the preferred election and v1 for the unclean
+// election. Sarama has no ElectLeaders request, it is sent on a connection of its own.
+func (ca *DefaultSaramaClusterAdmin) ElectLeaders(electionType ElectionType, topicPartitions map[string][]int32) (map[string]map[int32]sarama.KError, error) {
+	controller, err := ca.client.Controller()
+	if err != nil {
+		return nil, err
+	}
+	req := &electLeadersRequest{
+		electionType:    electionType,
+		topicPartitions: topicPartitions,
+		timeout:         ca.client.Config().Admin.Timeout,
+	}
+	if electionType != PreferredElection {
+		req.version = 1
+	}
+	resp := &electLeadersResponse{}
+	if err := ca.sendRequest(controller, req, resp); err != nil {
+		return nil, err
+	}
+	if resp.err != sarama.ErrNoError {
+		return nil, resp.err
+	}
+	return resp.results, nil
+}
+
+func (ca *DefaultSaramaClusterAdmin) DescribeLogDirs(brokerIds []int32) (map[int32][]sarama.DescribeLogDirsResponseDirMetadata, error) {
+	return ca.adminClient.DescribeLogDirs(brokerIds)
+}
+
+// AlterReplicaLogDirs sends the AlterReplicaLogDirs request v0 to the broker hosting the replicas, on a connection of
+// its own as sarama has no request for it
+func (ca *DefaultSaramaClusterAdmin) AlterReplicaLogDirs(brokerId int32, logDirs map[string]map[string][]int32) (map[string]map[int32]sarama.KError, error) {
+	broker, err := ca.client.Broker(brokerId)
+	if err != nil {
+		return nil, err
+	}
+	resp := &alterReplicaLogDirsResponse{}
+	if err := ca.sendRequest(broker, &alterReplicaLogDirsRequest{logDirs: logDirs}, resp); err != nil {
+		return nil, err
+	}
+	return resp.results, nil
+}
+
+// DescribeClientQuotas leaves out the entities with other components than the user and the client id
+func (ca *DefaultSaramaClusterAdmin) DescribeClientQuotas(filter ClientQuotaFilter) ([]ClientQuota, error) {
+	components := make([]sarama.QuotaFilterComponent, 0, len(filter.Components))
+	for _, c := range filter.Components {
+		components = append(components, sarama.QuotaFilterComponent{
+			EntityType: sarama.QuotaEntityType(c.EntityType),
+			MatchType:  sarama.QuotaMatchType(c.Match),
+			Match:      c.Name,
+		})
+	}
+	entries, err := ca.adminClient.DescribeClientQuotas(components, filter.Strict)
+	if err != nil {
+		return nil, err
+	}
+
+	quotas := make([]ClientQuota, 0, len(entries))
+	for _, entry := range entries {
+		entity, ok := clientQuotaEntity(entry.Entity)
+		if !ok {
+			continue
+		}
+		values := make(map[QuotaKey]float64, len(entry.Values))
+		for k, v := range entry.Values {
+			values[QuotaKey(k)] = v
+		}
+		quotas = append(quotas, ClientQuota{Entity: entity, Values: values})
+	}
+	return quotas, nil
+}
+
+// AlterClientQuotas sends the AlterClientQuotas request to the controller with all the quotas to set and to remove, the
+// admin client only alters one quota per request
+func (ca *DefaultSaramaClusterAdmin) AlterClientQuotas(entity ClientQuotaEntity, set map[QuotaKey]float64, remove []QuotaKey, validateOnly bool) error {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	ops := make([]sarama.ClientQuotasOp, 0, len(set)+len(remove))
+	for _, k := range keys {
+		ops = append(ops, sarama.ClientQuotasOp{Key: k, Value: set[QuotaKey(k)]})
+	}
+	for _, k := range remove {
+		ops = append(ops, sarama.ClientQuotasOp{Key: string(k), Remove: true})
+	}
+
+	controller, err := ca.client.Controller()
+	if err != nil {
+		return err
+	}
+	resp, err := controller.AlterClientQuotas(&sarama.AlterClientQuotasRequest{
+		Entries:      []sarama.AlterClientQuotasEntry{{Entity: saramaQuotaEntity(entity), Ops: ops}},
+		ValidateOnly: validateOnly,
+	})
+	if err != nil {
+		return err
+	}
+	errs := make([]error, 0)
+	for _, entry := range resp.Entries {
+		if entry.ErrorCode == sarama.ErrNoError {
+			continue
+		}
+		if entry.ErrorMsg != nil && *entry.ErrorMsg != "" {
+			errs = append(errs, fmt.Errorf("%s: %s", entity, *entry.ErrorMsg))
+		} else {
+			errs = append(errs, fmt.Errorf("%s: %w", entity, entry.ErrorCode))
+		}
+	}
+	if len(errs) != 0 {
+		return errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
+	}
+	return nil
+}
+
+func (ca *DefaultSaramaClusterAdmin) DescribeUserScramCredentials(users []string) ([]*sarama.DescribeUserScramCredentialsResult, error) {
+	return ca.adminClient.DescribeUserScramCredentials(users)
+}
+
+func (ca *DefaultSaramaClusterAdmin) UpsertUserScramCredentials(upsert []sarama.AlterUserScramCredentialsUpsert) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	return ca.adminClient.UpsertUserScramCredentials(upsert)
+}
+
+func (ca *DefaultSaramaClusterAdmin) DeleteUserScramCredentials(delete []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	return ca.adminClient.DeleteUserScramCredentials(delete)
+}
+
+// sendRequest sends the request to the broker on a connection of its own, opened outside sarama
+func (ca *DefaultSaramaClusterAdmin) sendRequest(broker *sarama.Broker, req protocolRequest, resp protocolResponse) error {
+	conn, err := dialBroker(broker.Addr(), ca.client.Config())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.send(req, resp)
+}
+
+// Close closes the admin client along with the sarama client it was created from
+func (ca *DefaultSaramaClusterAdmin) Close() error {
+	return ca.adminClient.Close()
+}
+
 func (k *DefaultSaramaClient) Replicas(topic string, partitionId int32) ([]int32, error) {
 	return k.client.Replicas(topic, partitionId)
 }
 
+func (k *DefaultSaramaClient) InSyncReplicas(topic string, partitionId int32) ([]int32, error) {
+	return k.client.InSyncReplicas(topic, partitionId)
+}
+
+func (k *DefaultSaramaClient) Leader(topic string, partitionId int32) (*sarama.Broker, error) {
+	return k.client.Leader(topic, partitionId)
+}
+
 func (k *DefaultSaramaClient) Controller() (*sarama.Broker, error) {
 	return k.client.Controller()
 }
@@ -48,6 +258,10 @@ func (k *DefaultSaramaClient) RefreshMetadata() error {
 	return k.client.RefreshMetadata()
 }
 
+func (k *DefaultSaramaClient) Topics() ([]string, error) {
+	return k.client.Topics()
+}
+
 func (k *DefaultSaramaClient) Partitions(topic string) ([]int32, error) {
 	return k.client.Partitions(topic)
 }
@@ -60,6 +274,32 @@ func (k *DefaultSaramaClient) ID(broker *sarama.Broker) int32 {
 	return broker.ID()
 }
 
+// Rack returns the rack of the broker, empty when the broker has no rack or the metadata version does not include it
+func (k *DefaultSaramaClient) Rack(broker *sarama.Broker) string {
+	return broker.Rack()
+}
+
+// SupportsAPI tells whether all brokers of the cluster support the version of the Kafka API
+func (k *DefaultSaramaClient) SupportsAPI(apiKey int, version int) bool {
+	return supportsAPI(k.supportedAPIs, apiKey, version)
+}
+
+func (k *DefaultSaramaClient) GetOffset(topic string, partitionId int32, time int64) (int64, error) {
+	return k.client.GetOffset(topic, partitionId, time)
+}
+
+func (k *DefaultSaramaClient) NewConsumer() (sarama.Consumer, error) {
+	return sarama.NewConsumerFromClient(k.client)
+}
+
+// Close closes the sarama client and stops reloading the certificates
+func (k *DefaultSaramaClient) Close() error {
+	if k.config != nil {
+		k.config.closeCertReloader()
+	}
+	return k.client.Close()
+}
+
 func NewDefaultSaramaClusterAdmin(saramaClient sarama.Client) (SaramaClusterAdmin, error) {
 	a, err := sarama.NewClusterAdminFromClient(saramaClient)
 	if err != nil {
@@ -67,11 +307,12 @@ func NewDefaultSaramaClusterAdmin(saramaClient sarama.Client) (SaramaClusterAdmi
 	}
 	admin := DefaultSaramaClusterAdmin{
 		adminClient: a,
+		client:      saramaClient,
 	}
 	return &admin, nil
 }
 
-// Init kafka client point to sarama client
+// Init kafka client point to sarama client. On error, the clients are closed and the certificate reloader is stopped
 func NewDefaultSaramaClient(config *Config) (*DefaultSaramaClient, sarama.Client, error) {
 	if config == nil {
 		return nil, nil, errors.New("Cannot create client without kafka config")
@@ -83,12 +324,14 @@ func NewDefaultSaramaClient(config *Config) (*DefaultSaramaClient, sarama.Client
 
 	kc, err := config.newKafkaConfig()
 	if err != nil {
+		config.closeCertReloader()
 		return nil, nil, err
 	}
 
 	bootstrapServers := *(config.BootstrapServers)
 	c, err := sarama.NewClient(bootstrapServers, kc)
 	if err != nil {
+		config.closeCertReloader()
 		return nil, nil, err
 	}
 
@@ -100,10 +343,31 @@ func NewDefaultSaramaClient(config *Config) (*DefaultSaramaClient, sarama.Client
 
 	err = kafkaClient.populateAPIVersions()
 	if err != nil {
-		return kafkaClient, c, err
+		_ = kafkaClient.Close()
+		return nil, nil, err
 	}
 
-	err = kafkaClient.extractTopics()
+	if config.autoDetectKafkaVersion() {
+		version := detectKafkaVersion(kafkaClient.supportedAPIs)
+		if version != kc.Version {
+			// The protocol version of a client cannot change, open a new one with the detected version
+			if err := c.Close(); err != nil {
+				config.closeCertReloader()
+				return nil, nil, err
+			}
+			kc.Version = version
+			c, err = sarama.NewClient(bootstrapServers, kc)
+			if err != nil {
+				config.closeCertReloader()
+				return nil, nil, err
+			}
+			kafkaClient.client = c
+		}
+	}
 
-	return kafkaClient, c, err
+	if err := kafkaClient.extractTopics(); err != nil {
+		_ = kafkaClient.Close()
+		return nil, nil, err
+	}
+	return kafkaClient, c, nil
 }
@@ -11,9 +11,24 @@ type MockKafkaAdmin struct{
 	TopicNameExpected string
 	PartitionExpected int32
 	AssignmentExpected [][]int32
+
+	ListPartitionReassignmentsFn  func(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error)
+	AlterPartitionReassignmentsFn func(topic string, assignment [][]int32) error
+	DeleteRecordsFn               func(topic string, partitionOffsets map[int32]int64) error
+	ElectLeadersFn                func(electionType ElectionType, topicPartitions map[string][]int32) (map[string]map[int32]sarama.KError, error)
+	DescribeLogDirsFn             func(brokerIds []int32) (map[int32][]sarama.DescribeLogDirsResponseDirMetadata, error)
+	AlterReplicaLogDirsFn         func(brokerId int32, logDirs map[string]map[string][]int32) (map[string]map[int32]sarama.KError, error)
+	DescribeClientQuotasFn        func(filter ClientQuotaFilter) ([]ClientQuota, error)
+	AlterClientQuotasFn           func(entity ClientQuotaEntity, set map[QuotaKey]float64, remove []QuotaKey, validateOnly bool) error
+
+	DescribeUserScramCredentialsFn func(users []string) ([]*sarama.DescribeUserScramCredentialsResult, error)
+	AlterUserScramCredentialsFn    func(upsert []sarama.AlterUserScramCredentialsUpsert, delete []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error)
 }
 
 func (mca *MockKafkaAdmin) ListPartitionReassignments(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+	if mca.ListPartitionReassignmentsFn != nil {
+		return mca.ListPartitionReassignmentsFn(topic, partitions)
+	}
 	if topic != mca.TopicNameExpected {
 		t.Fatal("not expected: ", sarama.ErrInvalidTopic)
 	}
@@ -21,9 +36,48 @@ func (mca *MockKafkaAdmin) ListPartitionReassignments(topic string, partitions [
 }
 
 func (mca *MockKafkaAdmin) AlterPartitionReassignments(topic string, assignment [][]int32) error {
+	if mca.AlterPartitionReassignmentsFn != nil {
+		return mca.AlterPartitionReassignmentsFn(topic, assignment)
+	}
 	return nil
 }
 
+func (mca *MockKafkaAdmin) DeleteRecords(topic string, partitionOffsets map[int32]int64) error {
+	return mca.DeleteRecordsFn(topic, partitionOffsets)
+}
+
+func (mca *MockKafkaAdmin) ElectLeaders(electionType ElectionType, topicPartitions map[string][]int32) (map[string]map[int32]sarama.KError, error) {
+	return mca.ElectLeadersFn(electionType, topicPartitions)
+}
+
+func (mca *MockKafkaAdmin) DescribeLogDirs(brokerIds []int32) (map[int32][]sarama.DescribeLogDirsResponseDirMetadata, error) {
+	return mca.DescribeLogDirsFn(brokerIds)
+}
+
+func (mca *MockKafkaAdmin) AlterReplicaLogDirs(brokerId int32, logDirs map[string]map[string][]int32) (map[string]map[int32]sarama.KError, error) {
+	return mca.AlterReplicaLogDirsFn(brokerId, logDirs)
+}
+
+func (mca *MockKafkaAdmin) DescribeClientQuotas(filter ClientQuotaFilter) ([]ClientQuota, error) {
+	return mca.DescribeClientQuotasFn(filter)
+}
+
+func (mca *MockKafkaAdmin) AlterClientQuotas(entity ClientQuotaEntity, set map[QuotaKey]float64, remove []QuotaKey, validateOnly bool) error {
+	return mca.AlterClientQuotasFn(entity, set, remove, validateOnly)
+}
+
+func (mca *MockKafkaAdmin) DescribeUserScramCredentials(users []string) ([]*sarama.DescribeUserScramCredentialsResult, error) {
+	return mca.DescribeUserScramCredentialsFn(users)
+}
+
+func (mca *MockKafkaAdmin) UpsertUserScramCredentials(upsert []sarama.AlterUserScramCredentialsUpsert) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	return mca.AlterUserScramCredentialsFn(upsert, nil)
+}
+
+func (mca *MockKafkaAdmin) DeleteUserScramCredentials(delete []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error) {
+	return mca.AlterUserScramCredentialsFn(nil, delete)
+}
+
 type MockKafkaClient struct {
 	MockBrokers *sarama.MockBroker
 	MockVersion sarama.KafkaVersion
@@ -31,6 +85,24 @@ type MockKafkaClient struct {
 	TopicNameExpected string
 	PartitionExpected int32
 	AssignmentExpected [][]int32
+
+	// BrokersExpected is returned by Brokers, with the ids and racks of IDFn and RackFn
+	BrokersExpected []*sarama.Broker
+	IDFn            func(broker *sarama.Broker) int32
+	RackFn          func(broker *sarama.Broker) string
+	// TopicsExpected is returned by Topics
+	TopicsExpected []string
+	// ReplicasFn replaces the default replicas of the partitions 1 and 2
+	ReplicasFn func(topic string, partitionId int32) ([]int32, error)
+	// InSyncReplicasFn defaults to the replicas, LeaderFn to no leader
+	InSyncReplicasFn func(topic string, partitionId int32) ([]int32, error)
+	LeaderFn         func(topic string, partitionId int32) (*sarama.Broker, error)
+	// GetOffsetFn returns the offsets of the partitions, Consumer is returned by NewConsumer
+	GetOffsetFn func(topic string, partitionId int32, time int64) (int64, error)
+	Consumer    sarama.Consumer
+
+	// SupportedAPIs maps api keys to their max version, all APIs are supported when nil
+	SupportedAPIs map[int]int
 }
 
 func (mk *MockKafkaClient) InitKafkaClient() sarama.Client {
@@ -42,9 +114,13 @@ func (mk *MockKafkaClient) InitKafkaClient() sarama.Client {
 	return client
 }
 
+// newTestConfig is configured with MockVersion, the latest Kafka version when it is not set
 func (mk *MockKafkaClient) newTestConfig() *sarama.Config {
 	config := sarama.NewConfig()
 	config.Version = mk.MockVersion
+	if mk.MockVersion == (sarama.KafkaVersion{}) {
+		config.Version = sarama.MaxVersion
+	}
 	return config
 }
 
@@ -53,6 +129,9 @@ func (mk *MockKafkaClient) Client() sarama.Client {
 }
 
 func (mk *MockKafkaClient) Replicas(topic string, partitionId int32) ([]int32, error) {
+	if mk.ReplicasFn != nil {
+		return mk.ReplicasFn(topic, partitionId)
+	}
 	if partitionId == 1 {
 		return []int32{1}, nil
 	}
@@ -62,6 +141,20 @@ func (mk *MockKafkaClient) Replicas(topic string, partitionId int32) ([]int32, e
 	return nil, nil
 }
 
+func (mk *MockKafkaClient) InSyncReplicas(topic string, partitionId int32) ([]int32, error) {
+	if mk.InSyncReplicasFn != nil {
+		return mk.InSyncReplicasFn(topic, partitionId)
+	}
+	return mk.Replicas(topic, partitionId)
+}
+
+func (mk *MockKafkaClient) Leader(topic string, partitionId int32) (*sarama.Broker, error) {
+	if mk.LeaderFn != nil {
+		return mk.LeaderFn(topic, partitionId)
+	}
+	return nil, sarama.ErrLeaderNotAvailable
+}
+
 func (mk *MockKafkaClient) NewSaramaClusterAdmin() (SaramaClusterAdmin, error) {
 	return &MockKafkaAdmin{
 		TopicNameExpected: mk.TopicNameExpected,
@@ -76,6 +169,9 @@ func (mk *MockKafkaClient) Controller() (*sarama.Broker, error) {
 }
 
 func (mk *MockKafkaClient) Config() *sarama.Config {
+	if mk.MockBrokers == nil {
+		return mk.newTestConfig()
+	}
 	c := mk.InitKafkaClient()
 	return c.Config()
 }
@@ -84,14 +180,44 @@ func (mk *MockKafkaClient) RefreshMetadata() error {
 	return nil
 }
 
+func (mk *MockKafkaClient) Topics() ([]string, error) {
+	return mk.TopicsExpected, nil
+}
+
 func (mk *MockKafkaClient) Partitions(topic string) ([]int32, error) {
 	return []int32{1,2}, nil
 }
 
 func (mk *MockKafkaClient) Brokers() []*sarama.Broker {
-	return nil
+	return mk.BrokersExpected
 }
 
 func (mk *MockKafkaClient) ID(broker *sarama.Broker) int32 {
+	if mk.IDFn != nil {
+		return mk.IDFn(broker)
+	}
 	return 3
 }
+
+func (mk *MockKafkaClient) Rack(broker *sarama.Broker) string {
+	if mk.RackFn != nil {
+		return mk.RackFn(broker)
+	}
+	return ""
+}
+
+func (mk *MockKafkaClient) GetOffset(topic string, partitionId int32, time int64) (int64, error) {
+	return mk.GetOffsetFn(topic, partitionId, time)
+}
+
+func (mk *MockKafkaClient) NewConsumer() (sarama.Consumer, error) {
+	return mk.Consumer, nil
+}
+
+func (mk *MockKafkaClient) SupportsAPI(apiKey int, version int) bool {
+	if mk.SupportedAPIs == nil {
+		return true
+	}
+	maxVersion, ok := mk.SupportedAPIs[apiKey]
+	return ok && version <= maxVersion
+}
@@ -0,0 +1,400 @@
+package confluent
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	apiKeySaslHandshake    = 17
+	apiKeySaslAuthenticate = 36
+)
+
+var errInsufficientData = errors.New("insufficient data to decode the response")
+
+// protocolRequest is a request of the Kafka protocol sent through a brokerConn with the request header v1
+type protocolRequest interface {
+	apiKey() int16
+	apiVersion() int16
+	encode(e *protocolEncoder)
+}
+
+// protocolResponse decodes the body of the response to a request of the given version
+type protocolResponse interface {
+	decode(d *protocolDecoder, version int16)
+}
+
+// protocolEncoder encodes the non-flexible versions of the Kafka protocol
+// @ref https://kafka.apache.org/protocol#protocol_types
+type protocolEncoder struct {
+	buf []byte
+}
+
+func (e *protocolEncoder) putInt8(v int8) {
+	e.buf = append(e.buf, byte(v))
+}
+
+func (e *protocolEncoder) putInt16(v int16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *protocolEncoder) putInt32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *protocolEncoder) putString(s string) {
+	e.putInt16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *protocolEncoder) putNullableString(s *string) {
+	if s == nil {
+		e.putInt16(-1)
+		return
+	}
+	e.putString(*s)
+}
+
+func (e *protocolEncoder) putBytes(b []byte) {
+	e.putInt32(int32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *protocolEncoder) putArrayLength(n int) {
+	e.putInt32(int32(n))
+}
+
+func (e *protocolEncoder) putInt32Array(values []int32) {
+	e.putArrayLength(len(values))
+	for _, v := range values {
+		e.putInt32(v)
+	}
+}
+
+// protocolDecoder decodes the non-flexible versions of the Kafka protocol, the first error is kept and the following
+// reads return zero values
+type protocolDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *protocolDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.buf) {
+		d.err = errInsufficientData
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *protocolDecoder) getInt8() int8 {
+	b := d.next(1)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
+func (d *protocolDecoder) getInt16() int16 {
+	b := d.next(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (d *protocolDecoder) getInt32() int32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (d *protocolDecoder) getKError() sarama.KError {
+	return sarama.KError(d.getInt16())
+}
+
+func (d *protocolDecoder) getString() string {
+	s := d.getNullableString()
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (d *protocolDecoder) getNullableString() *string {
+	n := d.getInt16()
+	if n < 0 {
+		return nil
+	}
+	s := string(d.next(int(n)))
+	return &s
+}
+
+func (d *protocolDecoder) getBytes() []byte {
+	n := d.getInt32()
+	if n < 0 {
+		return nil
+	}
+	return d.next(int(n))
+}
+
+// getArrayLength returns the length of an array, 0 for a null array. The length is bounded by the remaining bytes,
+// so that a corrupted length does not allocate much.
+func (d *protocolDecoder) getArrayLength() int {
+	n := d.getInt32()
+	if n < 0 {
+		return 0
+	}
+	if int(n) > len(d.buf) {
+		d.err = errInsufficientData
+		return 0
+	}
+	return int(n)
+}
+
+func (d *protocolDecoder) getInt32Array() []int32 {
+	n := d.getArrayLength()
+	values := make([]int32, 0, n)
+	for i := 0; i < n; i++ {
+		values = append(values, d.getInt32())
+	}
+	return values
+}
+
+// brokerConn is a connection to a broker opened outside sarama, with the TLS and SASL settings of the sarama client, for
+// the APIs sarama has no request for. The GSSAPI mechanism is not supported.
+type brokerConn struct {
+	conn          net.Conn
+	config        *sarama.Config
+	correlationID int32
+}
+
+func dialBroker(addr string, config *sarama.Config) (*brokerConn, error) {
+	dialer := &net.Dialer{Timeout: config.Net.DialTimeout, KeepAlive: config.Net.KeepAlive}
+	var conn net.Conn
+	var err error
+	if config.Net.TLS.Enable {
+		tlsConfig := config.Net.TLS.Config
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b := &brokerConn{conn: conn, config: config}
+	if config.Net.SASL.Enable {
+		if err := b.authenticate(); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (b *brokerConn) Close() error {
+	return b.conn.Close()
+}
+
+// send sends the request and decodes its response
+// @ref https://kafka.apache.org/protocol#protocol_messages
+func (b *brokerConn) send(req protocolRequest, resp protocolResponse) error {
+	b.correlationID++
+	e := &protocolEncoder{}
+	e.putInt32(0) // size, set once encoded
+	e.putInt16(req.apiKey())
+	e.putInt16(req.apiVersion())
+	e.putInt32(b.correlationID)
+	e.putString(b.config.ClientID)
+	req.encode(e)
+	binary.BigEndian.PutUint32(e.buf[:4], uint32(len(e.buf)-4))
+
+	if b.config.Net.WriteTimeout > 0 {
+		_ = b.conn.SetWriteDeadline(time.Now().Add(b.config.Net.WriteTimeout))
+	}
+	if _, err := b.conn.Write(e.buf); err != nil {
+		return err
+	}
+
+	if b.config.Net.ReadTimeout > 0 {
+		_ = b.conn.SetReadDeadline(time.Now().Add(b.config.Net.ReadTimeout))
+	}
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(b.conn, header); err != nil {
+		return err
+	}
+	size := int32(binary.BigEndian.Uint32(header[:4]))
+	if size < 4 || size > sarama.MaxResponseSize {
+		return fmt.Errorf("invalid response size %d", size)
+	}
+	if correlationID := int32(binary.BigEndian.Uint32(header[4:])); correlationID != b.correlationID {
+		return fmt.Errorf("correlation id %d of the response does not match the request %d", correlationID, b.correlationID)
+	}
+	body := make([]byte, size-4)
+	if _, err := io.ReadFull(b.conn, body); err != nil {
+		return err
+	}
+
+	d := &protocolDecoder{buf: body}
+	resp.decode(d, req.apiVersion())
+	return d.err
+}
+
+// authenticate runs the SASL handshake v1, then the SASL authentication of the mechanism of the sarama config
+// @ref https://kafka.apache.org/protocol#sasl_handshake
+func (b *brokerConn) authenticate() error {
+	sasl := b.config.Net.SASL
+	mechanism := sasl.Mechanism
+	if mechanism == "" {
+		mechanism = sarama.SASLTypePlaintext
+	}
+	switch mechanism {
+	case sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512, sarama.SASLTypeOAuth:
+	default:
+		return fmt.Errorf("sasl mechanism %s is not supported on the connections opened outside sarama", mechanism)
+	}
+
+	handshake := &saslHandshakeResponse{}
+	if err := b.send(&saslHandshakeRequest{mechanism: string(mechanism)}, handshake); err != nil {
+		return err
+	}
+	if handshake.err != sarama.ErrNoError {
+		return fmt.Errorf("sasl handshake with mechanism %s failed, the broker enables %s: %w", mechanism, strings.Join(handshake.mechanisms, ", "), handshake.err)
+	}
+
+	switch mechanism {
+	case sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512:
+		if sasl.SCRAMClientGeneratorFunc == nil {
+			return fmt.Errorf("no SCRAM client generator for sasl mechanism %s", mechanism)
+		}
+		client := sasl.SCRAMClientGeneratorFunc()
+		if err := client.Begin(sasl.User, sasl.Password, sasl.AuthIdentity); err != nil {
+			return err
+		}
+		msg, err := client.Step("")
+		if err != nil {
+			return err
+		}
+		for !client.Done() {
+			challenge, err := b.saslAuthenticate([]byte(msg))
+			if err != nil {
+				return err
+			}
+			if msg, err = client.Step(string(challenge)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case sarama.SASLTypeOAuth:
+		if sasl.TokenProvider == nil {
+			return errors.New("no token provider for sasl mechanism OAUTHBEARER")
+		}
+		token, err := sasl.TokenProvider.Token()
+		if err != nil {
+			return err
+		}
+		_, err = b.saslAuthenticate(oauthBearerMessage(token))
+		return err
+	}
+	_, err := b.saslAuthenticate([]byte(sasl.AuthIdentity + "\x00" + sasl.User + "\x00" + sasl.Password))
+	return err
+}
+
+func (b *brokerConn) saslAuthenticate(authBytes []byte) ([]byte, error) {
+	resp := &saslAuthenticateResponse{}
+	if err := b.send(&saslAuthenticateRequest{authBytes: authBytes}, resp); err != nil {
+		return nil, err
+	}
+	if resp.err != sarama.ErrNoError {
+		if resp.errorMessage != nil && *resp.errorMessage != "" {
+			return nil, fmt.Errorf("sasl authentication failed: %s", *resp.errorMessage)
+		}
+		return nil, fmt.Errorf("sasl authentication failed: %w", resp.err)
+	}
+	return resp.authBytes, nil
+}
+
+// oauthBearerMessage returns the initial client response of RFC 7628 with the extensions of the token, sorted by key
+func oauthBearerMessage(token *sarama.AccessToken) []byte {
+	keys := make([]string, 0, len(token.Extensions))
+	for k := range token.Extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var ext strings.Builder
+	for _, k := range keys {
+		ext.WriteString("\x01" + k + "=" + token.Extensions[k])
+	}
+	return []byte("n,,\x01auth=Bearer " + token.Token + ext.String() + "\x01\x01")
+}
+
+type saslHandshakeRequest struct {
+	mechanism string
+}
+
+func (r *saslHandshakeRequest) apiKey() int16     { return apiKeySaslHandshake }
+func (r *saslHandshakeRequest) apiVersion() int16 { return 1 }
+
+func (r *saslHandshakeRequest) encode(e *protocolEncoder) {
+	e.putString(r.mechanism)
+}
+
+type saslHandshakeResponse struct {
+	err        sarama.KError
+	mechanisms []string
+}
+
+func (r *saslHandshakeResponse) decode(d *protocolDecoder, version int16) {
+	r.err = d.getKError()
+	n := d.getArrayLength()
+	for i := 0; i < n; i++ {
+		r.mechanisms = append(r.mechanisms, d.getString())
+	}
+}
+
+type saslAuthenticateRequest struct {
+	authBytes []byte
+}
+
+func (r *saslAuthenticateRequest) apiKey() int16     { return apiKeySaslAuthenticate }
+func (r *saslAuthenticateRequest) apiVersion() int16 { return 0 }
+
+func (r *saslAuthenticateRequest) encode(e *protocolEncoder) {
+	e.putBytes(r.authBytes)
+}
+
+type saslAuthenticateResponse struct {
+	err          sarama.KError
+	errorMessage *string
+	authBytes    []byte
+}
+
+func (r *saslAuthenticateResponse) decode(d *protocolDecoder, version int16) {
+	r.err = d.getKError()
+	r.errorMessage = d.getNullableString()
+	r.authBytes = d.getBytes()
+}
@@ -0,0 +1,225 @@
+package confluent
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/xdg/scram"
+)
+
+// protocolTestHandler decodes the body of a request and encodes the body of its response
+type protocolTestHandler func(version int16, req *protocolDecoder, resp *protocolEncoder)
+
+// protocolTestBroker answers the requests with the handler of their api key
+type protocolTestBroker struct {
+	t        *testing.T
+	listener net.Listener
+	handlers map[int16]protocolTestHandler
+}
+
+func newProtocolTestBroker(t *testing.T, handlers map[int16]protocolTestHandler) *protocolTestBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &protocolTestBroker{t: t, listener: listener, handlers: handlers}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *protocolTestBroker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+func (b *protocolTestBroker) Close() {
+	_ = b.listener.Close()
+}
+
+func (b *protocolTestBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		size := make([]byte, 4)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return
+		}
+		body := make([]byte, binary.BigEndian.Uint32(size))
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		req := &protocolDecoder{buf: body}
+		apiKey, version, correlationID := req.getInt16(), req.getInt16(), req.getInt32()
+		req.getString() // client id
+		handler, ok := b.handlers[apiKey]
+		if !ok {
+			b.t.Errorf("unexpected request %d", apiKey)
+			return
+		}
+
+		resp := &protocolEncoder{}
+		resp.putInt32(0)
+		resp.putInt32(correlationID)
+		handler(version, req, resp)
+		binary.BigEndian.PutUint32(resp.buf[:4], uint32(len(resp.buf)-4))
+		if _, err := conn.Write(resp.buf); err != nil {
+			return
+		}
+	}
+}
+
+// protocolTestClient is a sarama client whose brokers are all the test broker
+type protocolTestClient struct {
+	sarama.Client
+	broker *sarama.Broker
+	config *sarama.Config
+}
+
+func (c *protocolTestClient) Controller() (*sarama.Broker, error) {
+	return c.broker, nil
+}
+
+func (c *protocolTestClient) Broker(brokerID int32) (*sarama.Broker, error) {
+	return c.broker, nil
+}
+
+func (c *protocolTestClient) Config() *sarama.Config {
+	return c.config
+}
+
+func newProtocolTestAdmin(broker *protocolTestBroker) *DefaultSaramaClusterAdmin {
+	return &DefaultSaramaClusterAdmin{
+		client: &protocolTestClient{broker: sarama.NewBroker(broker.Addr()), config: sarama.NewConfig()},
+	}
+}
+
+func saslHandshakeHandler(t *testing.T, mechanism string) protocolTestHandler {
+	return func(version int16, req *protocolDecoder, resp *protocolEncoder) {
+		assert.Equal(t, int16(1), version)
+		requested := req.getString()
+		if requested != mechanism {
+			resp.putInt16(int16(sarama.ErrUnsupportedSASLMechanism))
+		} else {
+			resp.putInt16(0)
+		}
+		resp.putArrayLength(1)
+		resp.putString(mechanism)
+	}
+}
+
+func TestKafkaProtocol_SaslPlain(t *testing.T) {
+	broker := newProtocolTestBroker(t, map[int16]protocolTestHandler{
+		apiKeySaslHandshake: saslHandshakeHandler(t, "PLAIN"),
+		apiKeySaslAuthenticate: func(version int16, req *protocolDecoder, resp *protocolEncoder) {
+			if string(req.getBytes()) == "\x00alice\x00secret" {
+				resp.putInt16(0)
+				resp.putNullableString(nil)
+			} else {
+				message := "Authentication failed: Invalid username or password"
+				resp.putInt16(int16(sarama.ErrSASLAuthenticationFailed))
+				resp.putNullableString(&message)
+			}
+			resp.putBytes(nil)
+		},
+	})
+	defer broker.Close()
+
+	config := Config{Timeout: 10, SASLUsername: "alice", SASLPassword: "secret", SASLMechanism: "plain"}
+	kc, err := config.newKafkaConfig()
+	if !assert.NoError(t, err) {
+		return
+	}
+	conn, err := dialBroker(broker.Addr(), kc)
+	if assert.NoError(t, err) {
+		_ = conn.Close()
+	}
+
+	kc.Net.SASL.Password = "wrong"
+	_, err = dialBroker(broker.Addr(), kc)
+	assert.EqualError(t, err, "sasl authentication failed: Authentication failed: Invalid username or password")
+
+	kc.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+	kc.Net.SASL.TokenProvider = NewMDSTokenProvider(&MockHttpClient{})
+	_, err = dialBroker(broker.Addr(), kc)
+	assert.EqualError(t, err, "sasl handshake with mechanism OAUTHBEARER failed, the broker enables PLAIN: "+sarama.ErrUnsupportedSASLMechanism.Error())
+
+	kc.Net.SASL.Mechanism = sarama.SASLTypeGSSAPI
+	_, err = dialBroker(broker.Addr(), kc)
+	assert.EqualError(t, err, "sasl mechanism GSSAPI is not supported on the connections opened outside sarama")
+}
+
+func TestKafkaProtocol_SaslScram(t *testing.T) {
+	client, err := scramSHA512.NewClient("alice", "secret", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	credentials := client.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+	server, err := scramSHA512.NewServer(func(user string) (scram.StoredCredentials, error) {
+		assert.Equal(t, "alice", user)
+		return credentials, nil
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	conversation := server.NewConversation()
+
+	broker := newProtocolTestBroker(t, map[int16]protocolTestHandler{
+		apiKeySaslHandshake: saslHandshakeHandler(t, "SCRAM-SHA-512"),
+		apiKeySaslAuthenticate: func(version int16, req *protocolDecoder, resp *protocolEncoder) {
+			challenge, err := conversation.Step(string(req.getBytes()))
+			assert.NoError(t, err)
+			resp.putInt16(0)
+			resp.putNullableString(nil)
+			resp.putBytes([]byte(challenge))
+		},
+	})
+	defer broker.Close()
+
+	config := Config{Timeout: 10, SASLUsername: "alice", SASLPassword: "secret", SASLMechanism: "scram-sha512"}
+	kc, err := config.newKafkaConfig()
+	if !assert.NoError(t, err) {
+		return
+	}
+	conn, err := dialBroker(broker.Addr(), kc)
+	if assert.NoError(t, err) {
+		_ = conn.Close()
+	}
+	assert.True(t, conversation.Valid())
+}
+
+func TestKafkaProtocol_OAuthBearerMessage(t *testing.T) {
+	assert.Equal(t, "n,,\x01auth=Bearer abc\x01\x01", string(oauthBearerMessage(&sarama.AccessToken{Token: "abc"})))
+	assert.Equal(t, "n,,\x01auth=Bearer abc\x01a=1\x01b=2\x01\x01", string(oauthBearerMessage(&sarama.AccessToken{
+		Token:      "abc",
+		Extensions: map[string]string{"b": "2", "a": "1"},
+	})))
+}
+
+func TestKafkaProtocol_Decoder(t *testing.T) {
+	e := &protocolEncoder{}
+	e.putString("topic-1")
+	e.putNullableString(nil)
+	e.putInt32Array([]int32{1, 2})
+
+	d := &protocolDecoder{buf: e.buf}
+	assert.Equal(t, "topic-1", d.getString())
+	assert.Nil(t, d.getNullableString())
+	assert.Equal(t, []int32{1, 2}, d.getInt32Array())
+	assert.NoError(t, d.err)
+
+	// An array longer than the data is not allocated
+	d = &protocolDecoder{buf: []byte{0x7f, 0, 0, 0}}
+	assert.Equal(t, 0, d.getArrayLength())
+	assert.Equal(t, errInsufficientData, d.err)
+	assert.Equal(t, int16(0), d.getInt16())
+}
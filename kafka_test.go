@@ -0,0 +1,86 @@
+package confluent
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+// apiVersionsUpTo returns the API versions of the probes of all Kafka versions up to the given one
+func apiVersionsUpTo(version sarama.KafkaVersion) []sarama.ApiVersionsResponseKey {
+	maxVersions := make(map[int16]int16)
+	for _, probe := range kafkaVersionProbes {
+		if !version.IsAtLeast(probe.version) {
+			break
+		}
+		if v, ok := maxVersions[int16(probe.apiKey)]; !ok || int16(probe.apiVersion) > v {
+			maxVersions[int16(probe.apiKey)] = int16(probe.apiVersion)
+		}
+	}
+	blocks := make([]sarama.ApiVersionsResponseKey, 0, len(maxVersions))
+	for apiKey, maxVersion := range maxVersions {
+		blocks = append(blocks, sarama.ApiVersionsResponseKey{ApiKey: apiKey, MaxVersion: maxVersion})
+	}
+	return blocks
+}
+
+func TestKafka_UpdateClusterApiVersions(t *testing.T) {
+	cluster := make(map[int][2]int)
+	updateClusterApiVersions(&cluster, []sarama.ApiVersionsResponseKey{
+		{ApiKey: 0, MinVersion: 0, MaxVersion: 8},
+		{ApiKey: 50, MinVersion: 0, MaxVersion: 0},
+	})
+	updateClusterApiVersions(&cluster, []sarama.ApiVersionsResponseKey{
+		{ApiKey: 0, MinVersion: 3, MaxVersion: 7},
+	})
+	assert.Equal(t, [2]int{3, 7}, cluster[0])
+	assert.Equal(t, [2]int{0, 0}, cluster[50])
+
+	k := DefaultSaramaClient{supportedAPIs: cluster}
+	assert.True(t, k.SupportsAPI(0, 3))
+	assert.False(t, k.SupportsAPI(0, 2))
+	assert.False(t, k.SupportsAPI(0, 8))
+	assert.False(t, k.SupportsAPI(51, 0))
+}
+
+func TestKafka_DetectKafkaVersion(t *testing.T) {
+	for _, version := range []sarama.KafkaVersion{sarama.V0_10_0_0, sarama.V1_1_0_0, sarama.V2_4_0_0, sarama.V2_8_0_0} {
+		cluster := make(map[int][2]int)
+		updateClusterApiVersions(&cluster, apiVersionsUpTo(version))
+		assert.Equal(t, version, detectKafkaVersion(cluster))
+	}
+
+	// A broker of the cluster is older than the others
+	cluster := intersectApiVersions([][]sarama.ApiVersionsResponseKey{
+		apiVersionsUpTo(sarama.V2_8_0_0),
+		apiVersionsUpTo(sarama.V2_6_0_0),
+		apiVersionsUpTo(sarama.V2_8_0_0),
+	})
+	assert.Equal(t, sarama.V2_6_0_0, detectKafkaVersion(cluster))
+	_, ok := cluster[apiKeyDescribeUserScramCredentials]
+	assert.False(t, ok)
+
+	assert.Equal(t, sarama.V0_10_0_0, detectKafkaVersion(map[int][2]int{}))
+}
+
+func TestKafka_NewDefaultSaramaClientAutoVersion(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()),
+		"ApiVersionsRequest": sarama.NewMockWrapper(&sarama.ApiVersionsResponse{ApiKeys: apiVersionsUpTo(sarama.V2_7_0_0)}),
+	})
+
+	config := Config{BootstrapServers: &[]string{broker.Addr()}, Timeout: 10, KafkaVersion: "auto"}
+	kafkaClient, c, err := NewDefaultSaramaClient(&config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer c.Close()
+	assert.Equal(t, sarama.V2_7_0_0, c.Config().Version)
+	assert.True(t, kafkaClient.SupportsAPI(apiKeyDescribeUserScramCredentials, 0))
+	assert.False(t, kafkaClient.SupportsAPI(60, 0))
+}
@@ -0,0 +1,223 @@
+package confluent
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pavel-v-chernykh/keystore-go/v4"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	keystoreTypeJKS    = "JKS"
+	keystoreTypePKCS12 = "PKCS12"
+	keystoreTypePEM    = "PEM"
+)
+
+// tlsMaterial is the client certificate, key and CA certificates, each given inline as PEM or as the path of a PEM file
+type tlsMaterial struct {
+	clientCert          string
+	clientKey           string
+	caCert              string
+	clientKeyPassphrase string
+}
+
+// hasTLSMaterial tells whether the config has a certificate, a key, a keystore or a truststore
+func (co *Config) hasTLSMaterial() bool {
+	return co.ClientCert != "" || co.ClientCertKey != "" || co.CACert != "" || co.KeystoreLocation != "" || co.TruststoreLocation != ""
+}
+
+// tlsMaterial returns the PEM certificates and key of the config, decoding the keystore and the truststore if any
+func (co *Config) tlsMaterial() (*tlsMaterial, error) {
+	m := &tlsMaterial{
+		clientCert:          co.ClientCert,
+		clientKey:           co.ClientCertKey,
+		caCert:              co.CACert,
+		clientKeyPassphrase: co.ClientCertKeyPassphrase,
+	}
+
+	if co.KeystoreLocation != "" {
+		if co.ClientCert != "" || co.ClientCertKey != "" {
+			return nil, errors.New("[ERROR] KeystoreLocation cannot be used along with ClientCert and ClientCertKey")
+		}
+		keyPassword := co.KeyPassword
+		if keyPassword == "" {
+			keyPassword = co.KeystorePassword
+		}
+		storeType, err := keystoreType(co.KeystoreType, co.KeystoreLocation)
+		if err != nil {
+			return nil, err
+		}
+		if storeType == keystoreTypePEM {
+			// The certificate chain and the key are in the same file
+			m.clientCert, m.clientKey, m.clientKeyPassphrase = co.KeystoreLocation, co.KeystoreLocation, keyPassword
+		} else {
+			m.clientCert, m.clientKey, err = loadKeystore(co.KeystoreLocation, storeType, co.KeystorePassword, keyPassword)
+			if err != nil {
+				return nil, fmt.Errorf("[ERROR] Unable to load the keystore %s: %w", co.KeystoreLocation, err)
+			}
+			m.clientKeyPassphrase = ""
+		}
+	}
+
+	if co.TruststoreLocation != "" {
+		if co.CACert != "" {
+			return nil, errors.New("[ERROR] TruststoreLocation cannot be used along with CACert")
+		}
+		storeType, err := keystoreType(co.TruststoreType, co.TruststoreLocation)
+		if err != nil {
+			return nil, err
+		}
+		if storeType == keystoreTypePEM {
+			m.caCert = co.TruststoreLocation
+		} else {
+			m.caCert, err = loadTruststore(co.TruststoreLocation, storeType, co.TruststorePassword)
+			if err != nil {
+				return nil, fmt.Errorf("[ERROR] Unable to load the truststore %s: %w", co.TruststoreLocation, err)
+			}
+		}
+	}
+	return m, nil
+}
+
+// keystoreType validates the type of the store, or guesses it from the file extension.
+// Like the Java clients, files without a known extension are JKS stores.
+func keystoreType(storeType, location string) (string, error) {
+	switch t := strings.ToUpper(storeType); t {
+	case keystoreTypeJKS, keystoreTypePKCS12, keystoreTypePEM:
+		return t, nil
+	case "":
+	default:
+		return "", fmt.Errorf("[ERROR] Invalid store type \"%s\": can only be \"JKS\", \"PKCS12\" or \"PEM\"", storeType)
+	}
+
+	switch strings.ToLower(filepath.Ext(location)) {
+	case ".p12", ".pfx", ".pkcs12":
+		return keystoreTypePKCS12, nil
+	case ".pem", ".crt", ".cer":
+		return keystoreTypePEM, nil
+	}
+	return keystoreTypeJKS, nil
+}
+
+// loadKeystore returns the certificate chain and the unencrypted key of the only private key of the store as PEM
+func loadKeystore(location, storeType, storePassword, keyPassword string) (string, string, error) {
+	content, err := ioutil.ReadFile(location)
+	if err != nil {
+		return "", "", err
+	}
+
+	var chain [][]byte
+	var keyDer []byte
+	switch storeType {
+	case keystoreTypePKCS12:
+		// PKCS12 keys are protected by the store password
+		key, cert, caCerts, err := pkcs12.DecodeChain(content, storePassword)
+		if err != nil {
+			return "", "", err
+		}
+		if keyDer, err = x509.MarshalPKCS8PrivateKey(key); err != nil {
+			return "", "", err
+		}
+		chain = append(chain, cert.Raw)
+		for _, c := range caCerts {
+			chain = append(chain, c.Raw)
+		}
+	default:
+		ks, err := loadJKS(content, storePassword)
+		if err != nil {
+			return "", "", err
+		}
+		aliases := make([]string, 0)
+		for _, alias := range ks.Aliases() {
+			if ks.IsPrivateKeyEntry(alias) {
+				aliases = append(aliases, alias)
+			}
+		}
+		if len(aliases) != 1 {
+			return "", "", fmt.Errorf("expected exactly one private key in the keystore, found %d", len(aliases))
+		}
+		entry, err := ks.GetPrivateKeyEntry(aliases[0], []byte(keyPassword))
+		if err != nil {
+			return "", "", err
+		}
+		keyDer = entry.PrivateKey
+		for _, c := range entry.CertificateChain {
+			chain = append(chain, c.Content)
+		}
+	}
+
+	return encodeCertificates(chain), string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})), nil
+}
+
+// loadTruststore returns the trusted certificates of the store as PEM
+func loadTruststore(location, storeType, password string) (string, error) {
+	content, err := ioutil.ReadFile(location)
+	if err != nil {
+		return "", err
+	}
+
+	var certs [][]byte
+	switch storeType {
+	case keystoreTypePKCS12:
+		// Truststores made by keytool only hold certificates marked as trusted, other stores are read as keystores
+		trusted, err := pkcs12.DecodeTrustStore(content, password)
+		if err == nil {
+			for _, c := range trusted {
+				certs = append(certs, c.Raw)
+			}
+			break
+		}
+		blocks, pemErr := pkcs12.ToPEM(content, password)
+		if pemErr != nil {
+			return "", err
+		}
+		for _, b := range blocks {
+			if b.Type == "CERTIFICATE" {
+				certs = append(certs, b.Bytes)
+			}
+		}
+	default:
+		ks, err := loadJKS(content, password)
+		if err != nil {
+			return "", err
+		}
+		for _, alias := range ks.Aliases() {
+			if !ks.IsTrustedCertificateEntry(alias) {
+				continue
+			}
+			entry, err := ks.GetTrustedCertificateEntry(alias)
+			if err != nil {
+				return "", err
+			}
+			certs = append(certs, entry.Certificate.Content)
+		}
+	}
+
+	if len(certs) == 0 {
+		return "", errors.New("no trusted certificate found")
+	}
+	return encodeCertificates(certs), nil
+}
+
+func loadJKS(content []byte, password string) (keystore.KeyStore, error) {
+	ks := keystore.New(keystore.WithOrderedAliases())
+	if err := ks.Load(bytes.NewReader(content), []byte(password)); err != nil {
+		return ks, err
+	}
+	return ks, nil
+}
+
+func encodeCertificates(certs [][]byte) string {
+	var buf bytes.Buffer
+	for _, der := range certs {
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+	return buf.String()
+}
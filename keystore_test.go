@@ -0,0 +1,200 @@
+package confluent
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pavel-v-chernykh/keystore-go/v4"
+	"github.com/stretchr/testify/assert"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// testKeystorePKCS12 holds testClientCert and testClientKey, made by OpenSSL 3 with the default PBES2 AES-256-CBC
+// encryption and a SHA-256 MAC, password "changeit"
+const testKeystorePKCS12 = `MIIEWQIBAzCCBA8GCSqGSIb3DQEHAaCCBAAEggP8MIID+DCCApIGCSqGSIb3DQEHBqCCAoMwggJ/
+AgEAMIICeAYJKoZIhvcNAQcBMFcGCSqGSIb3DQEFDTBKMCkGCSqGSIb3DQEFDDAcBAgCJsyg/AHz
+vAICCAAwDAYIKoZIhvcNAgkFADAdBglghkgBZQMEASoEEHk5dqFhPrRUOYYjvAfO3/CAggIQ4TqC
+/RcnGZjPrv5sOQ2wHKZG0V+6FxGHy3DQILFPQtjDxK1oosvcN3R7XZs8SPIpqVJrplnJZEzjNZKb
+9nfBMQIA/mmW9ui/bk8JhjSE5ItFcXUDPce9N1jIePG6wkQMgr1QTVWyuN/K//kYsSOmmOGeRWsN
+uGxGFoOkNS5GC5jVdrf2asXXe75pxSm/WmnTzPqMXI339vbxsmJyMSB62yiNGqhN6sN71Xs/6CWs
+3cwOsU50cV8b5H8WfzzpTc+soR2HLClogaIBMI2I2ROzgZK4J9dSEhSnU9U83mgzXMOfdyjJqtWX
+MJKvuDvRIC/9H5QSlDgNA6dF4dtkiTnix9KiIgfynqs5t7sciQ+sRhGAidizhBCCeKD61W4Pe9wa
+feIN/jSEwKleDdlp/Q0cfifFQ04EYTFCXJpKAAVDnhQmpSvN7CO0SYKoJ0zFIM8cerQ1yvF2Tqmy
+n9AUKSawkppI8OooZxA6tDA2J7BTzeomaLb4WRqFfvNMSBrpNAzfFxdhUkgCZ2b4aVXhUG8FVefI
+hW9vAqDFx8ky6yGbynklVnze43L6X+p3Hbng6IS8Lo6/80BFkqy8aT4fWXwyvvLajPO7qTC9IJJL
+yS6iGMDeVNUoS5pga/wIVs3dvyBg4otXregmy4mmo902m8QOb19HBVOu6QMEPPA3FTIqSrXHfW3t
+zxsIPsin73uRiVnzMIIBXgYJKoZIhvcNAQcBoIIBTwSCAUswggFHMIIBQwYLKoZIhvcNAQwKAQKg
+ge8wgewwVwYJKoZIhvcNAQUNMEowKQYJKoZIhvcNAQUMMBwECFnsoiv5QiAIAgIIADAMBggqhkiG
+9w0CCQUAMB0GCWCGSAFlAwQBKgQQ61nwlVR83U8yzigFczhacQSBkFAo03B49GnyVY4+XfP95Vvj
+tIAV4dtDzEdKfHqvGXx2rsin93x9rLBDn2OT1wwOUYjYAy5+Pl2Ak/5hfo3jHHRiMx0Q+GM9hAHo
+pHc0b+xYDDxRSSPTpO9dhCYmQUzCOmdOnOaH/8RnZ6bt7lG4gC3XWt9gaBnQ9Q6qvs55nvGwVVKn
+7KBelnc7GC2r+rZbXDFCMBsGCSqGSIb3DQEJFDEOHgwAYwBsAGkAZQBuAHQwIwYJKoZIhvcNAQkV
+MRYEFIsLEy/ZFnWdN46o+65Gxr6vSqOJMEEwMTANBglghkgBZQMEAgEFAAQgW9YLkVnwtL7Am8b1
+TWGnzjU6ZMHtBipYcDcViyKZ57EECGeq+Y5XybTDAgIIAA==`
+
+func writeTestBinaryFile(t *testing.T, name string, content []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestJKS(t *testing.T, storePassword, keyPassword string, client, ca *testCertificate) []byte {
+	ks := keystore.New()
+	if client != nil {
+		keyDer, err := x509.MarshalPKCS8PrivateKey(client.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ks.SetPrivateKeyEntry("client", keystore.PrivateKeyEntry{
+			CreationTime:     time.Now(),
+			PrivateKey:       keyDer,
+			CertificateChain: []keystore.Certificate{{Type: "X509", Content: client.cert.Raw}},
+		}, []byte(keyPassword))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ca != nil {
+		err := ks.SetTrustedCertificateEntry("ca", keystore.TrustedCertificateEntry{
+			CreationTime: time.Now(),
+			Certificate:  keystore.Certificate{Type: "X509", Content: ca.cert.Raw},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := ks.Store(&buf, []byte(storePassword)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// assertTrusts checks that the server certificate is verified with the root CAs of the config
+func assertTrusts(t *testing.T, roots *x509.CertPool, server *testCertificate) {
+	if assert.NotNil(t, roots) {
+		_, err := server.cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: "broker.example.com"})
+		assert.NoError(t, err)
+	}
+}
+
+func TestKeystore_PKCS12(t *testing.T) {
+	der, _ := base64.StdEncoding.DecodeString(strings.ReplaceAll(testKeystorePKCS12, "\n", ""))
+	config := Config{KeystoreLocation: writeTestBinaryFile(t, "keystore.p12", der), KeystorePassword: "changeit"}
+	tlsConfig, err := config.NewTLSConfig()
+	if assert.NoError(t, err) && assert.Equal(t, 1, len(tlsConfig.Certificates)) {
+		expected, _ := loadX509KeyPair(testClientCert, testClientKey, "")
+		assert.Equal(t, expected.Certificate, tlsConfig.Certificates[0].Certificate)
+	}
+
+	config.KeystorePassword = "wrong"
+	_, err = config.NewTLSConfig()
+	assert.NotNil(t, err)
+}
+
+func TestKeystore_PKCS12Truststore(t *testing.T) {
+	ca := newTestCertificate(t, "ca", time.Now().Add(time.Hour), nil)
+	server := newTestCertificate(t, "broker.example.com", time.Now().Add(time.Hour), ca)
+	client := newTestCertificate(t, "client", time.Now().Add(time.Hour), ca)
+
+	// Truststore made by keytool
+	trustStore, err := pkcs12.EncodeTrustStore(rand.Reader, []*x509.Certificate{ca.cert}, "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := Config{TruststoreLocation: writeTestBinaryFile(t, "truststore.p12", trustStore), TruststorePassword: "changeit"}
+	tlsConfig, err := config.NewTLSConfig()
+	if assert.NoError(t, err) {
+		assertTrusts(t, tlsConfig.RootCAs, server)
+	}
+
+	// Keystore used as a truststore, with the legacy PKCS12 encryption
+	keyStore, err := pkcs12.Encode(rand.Reader, client.key, client.cert, []*x509.Certificate{ca.cert}, "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = Config{
+		KeystoreLocation:   writeTestBinaryFile(t, "keystore.pfx", keyStore),
+		KeystorePassword:   "changeit",
+		TruststoreLocation: writeTestBinaryFile(t, "truststore.pfx", keyStore),
+		TruststorePassword: "changeit",
+	}
+	tlsConfig, err = config.NewTLSConfig()
+	if assert.NoError(t, err) {
+		assertTrusts(t, tlsConfig.RootCAs, server)
+		if assert.Equal(t, 1, len(tlsConfig.Certificates)) {
+			assert.Equal(t, [][]byte{client.cert.Raw, ca.cert.Raw}, tlsConfig.Certificates[0].Certificate)
+		}
+	}
+}
+
+func TestKeystore_JKS(t *testing.T) {
+	ca := newTestCertificate(t, "ca", time.Now().Add(time.Hour), nil)
+	server := newTestCertificate(t, "broker.example.com", time.Now().Add(time.Hour), ca)
+	client := newTestCertificate(t, "client", time.Now().Add(time.Hour), ca)
+
+	config := Config{
+		TLSEnabled:         true,
+		Timeout:            10,
+		KeystoreLocation:   writeTestBinaryFile(t, "keystore.jks", newTestJKS(t, "storepass", "keypass", client, nil)),
+		KeystorePassword:   "storepass",
+		KeyPassword:        "keypass",
+		TruststoreLocation: writeTestBinaryFile(t, "truststore", newTestJKS(t, "changeit", "", nil, ca)),
+		TruststorePassword: "changeit",
+	}
+	kc, err := config.newKafkaConfig()
+	if assert.NoError(t, err) {
+		assert.True(t, kc.Net.TLS.Enable)
+		assertTrusts(t, kc.Net.TLS.Config.RootCAs, server)
+		if assert.Equal(t, 1, len(kc.Net.TLS.Config.Certificates)) {
+			assert.Equal(t, client.cert.Raw, kc.Net.TLS.Config.Certificates[0].Certificate[0])
+		}
+	}
+
+	config.KeyPassword = "wrong"
+	_, err = config.newKafkaConfig()
+	assert.NotNil(t, err)
+
+	config.KeyPassword = ""
+	config.KeystorePassword = "wrong"
+	_, err = config.newKafkaConfig()
+	assert.NotNil(t, err)
+}
+
+func TestKeystore_PEM(t *testing.T) {
+	ca := newTestCertificate(t, "ca", time.Now().Add(time.Hour), nil)
+	server := newTestCertificate(t, "broker.example.com", time.Now().Add(time.Hour), ca)
+
+	config := Config{
+		KeystoreLocation:   writeTestFile(t, "keystore.pem", testClientCert+testClientKeyPKCS8AES),
+		KeyPassword:        "changeit",
+		TruststoreLocation: writeTestFile(t, "ca.crt", ca.certPEM),
+	}
+	tlsConfig, err := config.NewTLSConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, len(tlsConfig.Certificates))
+		assertTrusts(t, tlsConfig.RootCAs, server)
+	}
+}
+
+func TestKeystore_Errors(t *testing.T) {
+	for name, config := range map[string]Config{
+		"keystore and client cert": {KeystoreLocation: "keystore.jks", ClientCert: testClientCert, ClientCertKey: testClientKey},
+		"truststore and ca cert":   {TruststoreLocation: "truststore.jks", CACert: testClientCert},
+		"invalid type":             {KeystoreLocation: "keystore.jks", KeystoreType: "BKS"},
+		"missing file":             {TruststoreLocation: filepath.Join(t.TempDir(), "truststore.jks")},
+		"not a keystore":           {KeystoreLocation: writeTestFile(t, "keystore.jks", "garbage"), KeystorePassword: "changeit"},
+	} {
+		_, err := config.NewTLSConfig()
+		assert.NotNil(t, err, name)
+	}
+}
@@ -0,0 +1,242 @@
+package confluent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Shopify/sarama"
+)
+
+// LogDir is a log directory of a broker with the replicas it holds
+type LogDir struct {
+	BrokerID int32
+	Path     string
+	// Error is empty unless the log directory is offline or cannot be read
+	Error    string
+	Replicas []LogDirReplica
+}
+
+// LogDirReplica is the log of a replica in a log directory
+type LogDirReplica struct {
+	Topic     string
+	Partition int32
+	// Size is the size of the log segments in bytes
+	Size int64
+	// OffsetLag is the lag of the log end offset behind the high watermark, or behind the current log for a future log
+	OffsetLag int64
+	// IsFuture tells whether the log is being created by AlterReplicaLogDirs to replace the current log of the replica
+	IsFuture bool
+}
+
+// ReplicaLogDirMove is the move of the replica of a partition hosted by a broker to another log directory of the broker
+type ReplicaLogDirMove struct {
+	BrokerID  int32
+	Topic     string
+	Partition int32
+	Path      string
+}
+
+// TopicDiskUsage is the size of the logs of a topic on all the brokers of the cluster, including the future logs of
+// the replicas being moved between log directories
+type TopicDiskUsage struct {
+	Topic string
+	Size  int64
+	// Brokers is the size of the logs of the topic on each broker
+	Brokers map[int32]int64
+}
+
+// DescribeLogDirs returns the log directories of the brokers, or of all the brokers when brokerIds is empty, sorted by
+// broker and path. The replicas are sorted by topic and partition.
+// @ref https://kafka.apache.org/protocol#The_Messages_DescribeLogDirs
+func (c *Client) DescribeLogDirs(brokerIds []int32) ([]LogDir, error) {
+	if err := c.requireAPI(apiKeyDescribeLogDirs, 0, "DescribeLogDirs"); err != nil {
+		return nil, err
+	}
+	if err := c.saramaClient.RefreshMetadata(); err != nil {
+		return nil, err
+	}
+
+	known := make(map[int32]bool)
+	for _, b := range c.saramaClient.Brokers() {
+		known[c.saramaClient.ID(b)] = true
+	}
+	if len(brokerIds) == 0 {
+		for id := range known {
+			brokerIds = append(brokerIds, id)
+		}
+	}
+	// The sarama admin client waits forever for the brokers it does not know
+	for _, id := range brokerIds {
+		if !known[id] {
+			return nil, fmt.Errorf("unknown broker %d", id)
+		}
+	}
+
+	response, err := c.saramaClusterAdmin.DescribeLogDirs(brokerIds)
+	if err != nil {
+		return nil, err
+	}
+
+	logDirs := make([]LogDir, 0)
+	for brokerId, dirs := range response {
+		for _, d := range dirs {
+			logDir := LogDir{BrokerID: brokerId, Path: d.Path, Replicas: make([]LogDirReplica, 0)}
+			if d.ErrorCode != sarama.ErrNoError {
+				logDir.Error = d.ErrorCode.Error()
+			}
+			for _, t := range d.Topics {
+				for _, p := range t.Partitions {
+					logDir.Replicas = append(logDir.Replicas, LogDirReplica{
+						Topic:     t.Topic,
+						Partition: p.PartitionID,
+						Size:      p.Size,
+						OffsetLag: p.OffsetLag,
+						IsFuture:  p.IsTemporary,
+					})
+				}
+			}
+			sort.Slice(logDir.Replicas, func(i, j int) bool {
+				if logDir.Replicas[i].Topic != logDir.Replicas[j].Topic {
+					return logDir.Replicas[i].Topic < logDir.Replicas[j].Topic
+				}
+				return logDir.Replicas[i].Partition < logDir.Replicas[j].Partition
+			})
+			logDirs = append(logDirs, logDir)
+		}
+	}
+	sort.Slice(logDirs, func(i, j int) bool {
+		if logDirs[i].BrokerID != logDirs[j].BrokerID {
+			return logDirs[i].BrokerID < logDirs[j].BrokerID
+		}
+		return logDirs[i].Path < logDirs[j].Path
+	})
+	return logDirs, nil
+}
+
+// AlterReplicaLogDirs moves the replicas to other log directories of their broker. The brokers copy the logs in the
+// background, the moves are completed when DescribeLogDirs no longer returns future logs. The requests are sent on
+// broker connections opened outside sarama, the "gssapi" SASL mechanism is not supported.
+// @ref https://kafka.apache.org/protocol#The_Messages_AlterReplicaLogDirs
+func (c *Client) AlterReplicaLogDirs(moves []ReplicaLogDirMove) error {
+	if err := c.requireAPI(apiKeyAlterReplicaLogDirs, 0, "AlterReplicaLogDirs"); err != nil {
+		return err
+	}
+
+	brokers := make([]int32, 0)
+	logDirs := make(map[int32]map[string]map[string][]int32)
+	for _, m := range moves {
+		if m.Path == "" {
+			return fmt.Errorf("no log directory for the partition %d of topic %s", m.Partition, m.Topic)
+		}
+		if _, ok := logDirs[m.BrokerID]; !ok {
+			brokers = append(brokers, m.BrokerID)
+			logDirs[m.BrokerID] = make(map[string]map[string][]int32)
+		}
+		if _, ok := logDirs[m.BrokerID][m.Path]; !ok {
+			logDirs[m.BrokerID][m.Path] = make(map[string][]int32)
+		}
+		logDirs[m.BrokerID][m.Path][m.Topic] = append(logDirs[m.BrokerID][m.Path][m.Topic], m.Partition)
+	}
+
+	errs := make([]error, 0)
+	for _, b := range brokers {
+		results, err := c.saramaClusterAdmin.AlterReplicaLogDirs(b, logDirs[b])
+		if err != nil {
+			return err
+		}
+		for topic, partitions := range results {
+			for p, code := range partitions {
+				if code != sarama.ErrNoError {
+					errs = append(errs, fmt.Errorf("[%s-%d] on broker %d: %s", topic, p, b, code.Error()))
+				}
+			}
+		}
+	}
+	if len(errs) != 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
+	}
+	return nil
+}
+
+// DescribeTopicsDiskUsage returns the size of the logs of each topic on the brokers, or on all the brokers when
+// brokerIds is empty, sorted by topic. The log directories which cannot be read are ignored.
+func (c *Client) DescribeTopicsDiskUsage(brokerIds []int32) ([]TopicDiskUsage, error) {
+	logDirs, err := c.DescribeLogDirs(brokerIds)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make(map[string]*TopicDiskUsage)
+	for _, d := range logDirs {
+		for _, r := range d.Replicas {
+			usage, ok := usages[r.Topic]
+			if !ok {
+				usage = &TopicDiskUsage{Topic: r.Topic, Brokers: make(map[int32]int64)}
+				usages[r.Topic] = usage
+			}
+			usage.Size += r.Size
+			usage.Brokers[d.BrokerID] += r.Size
+		}
+	}
+
+	result := make([]TopicDiskUsage, 0, len(usages))
+	for _, u := range usages {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Topic < result[j].Topic })
+	return result, nil
+}
+
+// alterReplicaLogDirsRequest is the AlterReplicaLogDirs request v0, of the log directories by path of the partitions of
+// each topic
+type alterReplicaLogDirsRequest struct {
+	logDirs map[string]map[string][]int32
+}
+
+func (r *alterReplicaLogDirsRequest) apiKey() int16     { return apiKeyAlterReplicaLogDirs }
+func (r *alterReplicaLogDirsRequest) apiVersion() int16 { return 0 }
+
+func (r *alterReplicaLogDirsRequest) encode(e *protocolEncoder) {
+	paths := make([]string, 0, len(r.logDirs))
+	for path := range r.logDirs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	e.putArrayLength(len(paths))
+	for _, path := range paths {
+		e.putString(path)
+		topics := make([]string, 0, len(r.logDirs[path]))
+		for topic := range r.logDirs[path] {
+			topics = append(topics, topic)
+		}
+		sort.Strings(topics)
+		e.putArrayLength(len(topics))
+		for _, topic := range topics {
+			e.putString(topic)
+			e.putInt32Array(r.logDirs[path][topic])
+		}
+	}
+}
+
+type alterReplicaLogDirsResponse struct {
+	results map[string]map[int32]sarama.KError
+}
+
+func (r *alterReplicaLogDirsResponse) decode(d *protocolDecoder, version int16) {
+	d.getInt32() // throttle time
+	r.results = make(map[string]map[int32]sarama.KError)
+	topics := d.getArrayLength()
+	for i := 0; i < topics; i++ {
+		topic := d.getString()
+		partitions := d.getArrayLength()
+		if r.results[topic] == nil {
+			r.results[topic] = make(map[int32]sarama.KError, partitions)
+		}
+		for j := 0; j < partitions; j++ {
+			partition := d.getInt32()
+			r.results[topic][partition] = d.getKError()
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package confluent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLogDirsMockClient() *MockKafkaClient {
+	mk := &MockKafkaClient{
+		BrokersExpected: []*sarama.Broker{sarama.NewBroker("broker-1:9092"), sarama.NewBroker("broker-2:9092")},
+	}
+	mk.IDFn = func(broker *sarama.Broker) int32 {
+		return int32(broker.Addr()[7] - '0')
+	}
+	return mk
+}
+
+func TestLogDirs_DescribeLogDirs(t *testing.T) {
+	admin := &MockKafkaAdmin{}
+	admin.DescribeLogDirsFn = func(brokerIds []int32) (map[int32][]sarama.DescribeLogDirsResponseDirMetadata, error) {
+		assert.ElementsMatch(t, []int32{1, 2}, brokerIds)
+		return map[int32][]sarama.DescribeLogDirsResponseDirMetadata{
+			2: {{ErrorCode: sarama.ErrKafkaStorageError, Path: "/data-1"}},
+			1: {
+				{Path: "/data-2", Topics: []sarama.DescribeLogDirsResponseTopic{
+					{Topic: "topic-2", Partitions: []sarama.DescribeLogDirsResponsePartition{{PartitionID: 0, Size: 10}}},
+					{Topic: "topic-1", Partitions: []sarama.DescribeLogDirsResponsePartition{
+						{PartitionID: 1, Size: 200, OffsetLag: 5, IsTemporary: true},
+						{PartitionID: 0, Size: 100},
+					}},
+				}},
+				{Path: "/data-1", Topics: []sarama.DescribeLogDirsResponseTopic{
+					{Topic: "topic-1", Partitions: []sarama.DescribeLogDirsResponsePartition{{PartitionID: 1, Size: 300}}},
+				}},
+			},
+		}, nil
+	}
+	c := NewClient(&MockHttpClient{}, newLogDirsMockClient(), admin)
+
+	logDirs, err := c.DescribeLogDirs(nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []LogDir{
+			{BrokerID: 1, Path: "/data-1", Replicas: []LogDirReplica{{Topic: "topic-1", Partition: 1, Size: 300}}},
+			{BrokerID: 1, Path: "/data-2", Replicas: []LogDirReplica{
+				{Topic: "topic-1", Partition: 0, Size: 100},
+				{Topic: "topic-1", Partition: 1, Size: 200, OffsetLag: 5, IsFuture: true},
+				{Topic: "topic-2", Partition: 0, Size: 10},
+			}},
+			{BrokerID: 2, Path: "/data-1", Error: sarama.ErrKafkaStorageError.Error(), Replicas: []LogDirReplica{}},
+		}, logDirs)
+	}
+
+	usages, err := c.DescribeTopicsDiskUsage(nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []TopicDiskUsage{
+			{Topic: "topic-1", Size: 600, Brokers: map[int32]int64{1: 600}},
+			{Topic: "topic-2", Size: 10, Brokers: map[int32]int64{1: 10}},
+		}, usages)
+	}
+
+	_, err = c.DescribeLogDirs([]int32{3})
+	assert.Equal(t, errors.New("unknown broker 3"), err)
+
+	oldVersion := newLogDirsMockClient()
+	oldVersion.MockVersion = sarama.V0_11_0_0
+	_, err = NewClient(&MockHttpClient{}, oldVersion, admin).DescribeLogDirs(nil)
+	assert.EqualError(t, err, "DescribeLogDirs v0 requires kafka 1.0.0 but the client is configured for kafka 0.11.0.0, set KafkaVersion >= 1.0.0 or auto")
+
+	_, err = NewClient(&MockHttpClient{}, nil, nil).DescribeLogDirs(nil)
+	assert.Equal(t, errors.New("DescribeLogDirs requires a kafka client"), err)
+}
+
+func TestLogDirs_AlterReplicaLogDirs(t *testing.T) {
+	calls := make(map[int32]map[string]map[string][]int32)
+	admin := &MockKafkaAdmin{}
+	admin.AlterReplicaLogDirsFn = func(brokerId int32, logDirs map[string]map[string][]int32) (map[string]map[int32]sarama.KError, error) {
+		calls[brokerId] = logDirs
+		if brokerId == 2 {
+			return map[string]map[int32]sarama.KError{"topic-1": {0: sarama.ErrLogDirNotFound}}, nil
+		}
+		return map[string]map[int32]sarama.KError{"topic-1": {0: sarama.ErrNoError, 1: sarama.ErrNoError}}, nil
+	}
+	mk := newLogDirsMockClient()
+	c := NewClient(&MockHttpClient{}, mk, admin)
+
+	err := c.AlterReplicaLogDirs([]ReplicaLogDirMove{
+		{BrokerID: 1, Topic: "topic-1", Partition: 0, Path: "/data-2"},
+		{BrokerID: 1, Topic: "topic-1", Partition: 1, Path: "/data-2"},
+		{BrokerID: 2, Topic: "topic-1", Partition: 0, Path: "/data-3"},
+	})
+	assert.Equal(t, errors.New("[topic-1-0] on broker 2: "+sarama.ErrLogDirNotFound.Error()+"\n"), err)
+	assert.Equal(t, map[int32]map[string]map[string][]int32{
+		1: {"/data-2": {"topic-1": {0, 1}}},
+		2: {"/data-3": {"topic-1": {0}}},
+	}, calls)
+
+	err = c.AlterReplicaLogDirs([]ReplicaLogDirMove{{BrokerID: 1, Topic: "topic-1", Partition: 0}})
+	assert.Equal(t, errors.New("no log directory for the partition 0 of topic topic-1"), err)
+
+	mk.SupportedAPIs = map[int]int{}
+	err = c.AlterReplicaLogDirs([]ReplicaLogDirMove{{BrokerID: 1, Topic: "topic-1", Partition: 0, Path: "/data-2"}})
+	assert.Equal(t, errors.New("AlterReplicaLogDirs v0 is not supported by all brokers of the cluster"), err)
+}
+
+func TestLogDirs_DefaultAdminAlterReplicaLogDirs(t *testing.T) {
+	broker := newProtocolTestBroker(t, map[int16]protocolTestHandler{
+		apiKeyAlterReplicaLogDirs: func(version int16, req *protocolDecoder, resp *protocolEncoder) {
+			assert.Equal(t, 2, req.getArrayLength())
+			assert.Equal(t, "/data/1", req.getString())
+			assert.Equal(t, 1, req.getArrayLength())
+			assert.Equal(t, "topic-1", req.getString())
+			assert.Equal(t, []int32{0, 2}, req.getInt32Array())
+			assert.Equal(t, "/data/2", req.getString())
+			assert.Equal(t, 1, req.getArrayLength())
+			assert.Equal(t, "topic-2", req.getString())
+			assert.Equal(t, []int32{1}, req.getInt32Array())
+			assert.NoError(t, req.err)
+
+			resp.putInt32(0)
+			resp.putArrayLength(2)
+			resp.putString("topic-1")
+			resp.putArrayLength(2)
+			resp.putInt32(0)
+			resp.putInt16(0)
+			resp.putInt32(2)
+			resp.putInt16(int16(sarama.ErrKafkaStorageError))
+			resp.putString("topic-2")
+			resp.putArrayLength(1)
+			resp.putInt32(1)
+			resp.putInt16(0)
+		},
+	})
+	defer broker.Close()
+	admin := newProtocolTestAdmin(broker)
+
+	results, err := admin.AlterReplicaLogDirs(1, map[string]map[string][]int32{
+		"/data/2": {"topic-2": {1}},
+		"/data/1": {"topic-1": {0, 2}},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]map[int32]sarama.KError{
+			"topic-1": {0: sarama.ErrNoError, 2: sarama.ErrKafkaStorageError},
+			"topic-2": {1: sarama.ErrNoError},
+		}, results)
+	}
+}
@@ -2,6 +2,18 @@ package confluent
 
 import (
 	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	// Renew the token of the Metadata Service this long before it expires
+	mdsTokenRefreshMargin = time.Minute
+	// Default lifetime of the tokens of the Metadata Service, confluent.metadata.server.token.max.lifetime.ms
+	mdsDefaultTokenLifetime = time.Hour
 )
 
 type Authenticate struct {
@@ -21,16 +33,86 @@ type AuthenticateError struct {
 	} `json:"errors"`
 }
 
+// Login returns a new bearer token of the Metadata Service, Authenticate also returns its lifetime
 func (c *Client) Login() (string, error) {
+	authenticate, err := c.Authenticate()
+	if err != nil {
+		return "", err
+	}
+	return authenticate.AuthToken, nil
+}
+
+// Authenticate returns a new bearer token of the Metadata Service along with its lifetime
+func (c *Client) Authenticate() (*Authenticate, error) {
 	u := "/security/1.0/authenticate"
 	authenReq, err := c.DoRequest("GET", u, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	var authenticate *Authenticate
 	err = json.Unmarshal(authenReq, &authenticate)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return authenticate.AuthToken, nil
+	if authenticate == nil {
+		return nil, errors.New("empty authentication response")
+	}
+	return authenticate, nil
+}
+
+// MDSTokenProvider implements sarama.AccessTokenProvider for SASL/OAUTHBEARER with the bearer token
+// of the Metadata Service. The token is renewed through the authenticate endpoint before it expires.
+// A DefaultHttpClient is copied without its Token, so that the renewals always authenticate with the
+// username and password, other HttpClient implementations must not send the expired token.
+type MDSTokenProvider struct {
+	client *Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	now       func() time.Time
+}
+
+func NewMDSTokenProvider(httpClient HttpClient) *MDSTokenProvider {
+	if c, ok := httpClient.(*DefaultHttpClient); ok {
+		httpClient = c.withBasicAuth()
+	}
+	return &MDSTokenProvider{
+		client: &Client{httpClient: httpClient},
+		now:    time.Now,
+	}
+}
+
+// SetToken reuses a token already obtained through Client.Authenticate
+func (p *MDSTokenProvider) SetToken(authenticate *Authenticate) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setToken(authenticate)
+}
+
+// SetTokenString reuses a token already obtained through Client.Login. Its lifetime is unknown, it is renewed after
+// the default lifetime of the tokens of the Metadata Service, one hour, see SetToken otherwise.
+func (p *MDSTokenProvider) SetTokenString(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setToken(&Authenticate{AuthToken: token, ExpiresIn: int(mdsDefaultTokenLifetime / time.Second)})
+}
+
+func (p *MDSTokenProvider) Token() (*sarama.AccessToken, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == "" || !p.now().Add(mdsTokenRefreshMargin).Before(p.expiresAt) {
+		authenticate, err := p.client.Authenticate()
+		if err != nil {
+			return nil, err
+		}
+		p.setToken(authenticate)
+	}
+	return &sarama.AccessToken{Token: p.token}, nil
+}
+
+func (p *MDSTokenProvider) setToken(authenticate *Authenticate) {
+	p.token = authenticate.AuthToken
+	p.expiresAt = p.now().Add(time.Duration(authenticate.ExpiresIn) * time.Second)
 }
@@ -1,9 +1,12 @@
 package confluent
 
 import (
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -58,3 +61,79 @@ func TestLogin_FailWithWrongResponse(t *testing.T) {
 	_, err := c.Login()
 	assert.NotNil(t, err)
 }
+
+func TestLogin_MDSTokenProviderRefresh(t *testing.T) {
+	mock := MockHttpClient{}
+	calls := 0
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, "/security/1.0/authenticate", uri)
+		calls++
+		return []byte(fmt.Sprintf(`{"auth_token": "token-%d", "token_type": "Bearer", "expires_in": 3600}`, calls)), 200, "200", nil
+	}
+	now := time.Date(2021, 6, 14, 10, 0, 0, 0, time.UTC)
+	provider := NewMDSTokenProvider(&mock)
+	provider.now = func() time.Time { return now }
+
+	token, err := provider.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token.Token)
+
+	now = now.Add(30 * time.Minute)
+	token, err = provider.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token.Token)
+
+	now = now.Add(30 * time.Minute)
+	token, err = provider.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-2", token.Token)
+	assert.Equal(t, 2, calls)
+}
+
+func TestLogin_MDSTokenProviderReuseToken(t *testing.T) {
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		t.Fatal("the token given to the provider should be reused")
+		return nil, 0, "", nil
+	}
+	provider := NewMDSTokenProvider(&mock)
+	provider.SetToken(&Authenticate{AuthToken: "abcdefghizk", ExpiresIn: 3600})
+
+	token, err := provider.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdefghizk", token.Token)
+
+	// The token returned by Client.Login is reused as well
+	provider.SetTokenString("token-1")
+	token, err = provider.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token.Token)
+}
+
+func TestLogin_MDSTokenProviderRefreshWithBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "alice" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status_code": 401, "message": "Unauthorized"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"auth_token": "token-2", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer server.Close()
+
+	// The client logged in and its token has expired
+	httpClient := NewDefaultHttpClient(server.URL, "alice", "secret")
+	httpClient.Token = "token-1"
+	now := time.Date(2021, 6, 14, 10, 0, 0, 0, time.UTC)
+	provider := NewMDSTokenProvider(httpClient)
+	provider.now = func() time.Time { return now }
+	provider.SetToken(&Authenticate{AuthToken: "token-1", ExpiresIn: 3600})
+
+	now = now.Add(time.Hour)
+	token, err := provider.Token()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "token-2", token.Token)
+	}
+	assert.Equal(t, "token-1", httpClient.Token)
+}
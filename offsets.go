@@ -0,0 +1,91 @@
+package confluent
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// PartitionOffsets is the earliest and the latest offsets of a partition. The latest offset is the offset of the next
+// record, the high watermark.
+type PartitionOffsets struct {
+	Partition int32
+	Earliest  int64
+	Latest    int64
+	// AtTime is the offset of the first record at or after the time given to GetTopicOffsets, or the latest offset
+	// when there is no such record. It is nil without time.
+	AtTime *int64
+	// Count is the number of records between the watermarks, the transaction markers and the compacted records are
+	// counted
+	Count int64
+}
+
+// TopicOffsets is the offsets of the partitions of a topic, sorted by partition
+type TopicOffsets struct {
+	Topic      string
+	Partitions []PartitionOffsets
+	Count      int64
+}
+
+// GetTopicOffsets returns the earliest and the latest offsets of each partition of the topic, and the offsets at the
+// time when it is not nil
+// @ref https://kafka.apache.org/protocol#The_Messages_ListOffsets
+func (c *Client) GetTopicOffsets(topic string, at *time.Time) (*TopicOffsets, error) {
+	if c.saramaClient == nil {
+		return nil, errors.New("GetTopicOffsets requires a kafka client")
+	}
+	if err := c.saramaClient.RefreshMetadata(); err != nil {
+		return nil, err
+	}
+	partitions, err := c.saramaClient.Partitions(topic)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]int32, len(partitions))
+	copy(sorted, partitions)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	offsets := &TopicOffsets{Topic: topic, Partitions: make([]PartitionOffsets, 0, len(sorted))}
+	for _, p := range sorted {
+		po := PartitionOffsets{Partition: p}
+		if po.Earliest, err = c.saramaClient.GetOffset(topic, p, sarama.OffsetOldest); err != nil {
+			return nil, err
+		}
+		if po.Latest, err = c.saramaClient.GetOffset(topic, p, sarama.OffsetNewest); err != nil {
+			return nil, err
+		}
+		if at != nil {
+			offset, err := c.saramaClient.GetOffset(topic, p, at.UnixNano()/int64(time.Millisecond))
+			if err != nil {
+				return nil, err
+			}
+			if offset == -1 {
+				offset = po.Latest
+			}
+			po.AtTime = &offset
+		}
+		po.Count = po.Latest - po.Earliest
+		offsets.Count += po.Count
+		offsets.Partitions = append(offsets.Partitions, po)
+	}
+	return offsets, nil
+}
+
+// Lag returns the number of records of each partition after the committed offsets of a consumer group. The partitions
+// without committed offset, and those whose committed offset was deleted, lag by all their records.
+func (o *TopicOffsets) Lag(committed map[int32]int64) map[int32]int64 {
+	lag := make(map[int32]int64, len(o.Partitions))
+	for _, p := range o.Partitions {
+		offset, ok := committed[p.Partition]
+		if !ok || offset < p.Earliest {
+			offset = p.Earliest
+		}
+		if offset > p.Latest {
+			offset = p.Latest
+		}
+		lag[p.Partition] = p.Latest - offset
+	}
+	return lag
+}
@@ -0,0 +1,54 @@
+package confluent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsets_GetTopicOffsets(t *testing.T) {
+	mk := MockKafkaClient{}
+	mk.GetOffsetFn = func(topic string, partitionId int32, time int64) (int64, error) {
+		assert.Equal(t, "topic-1", topic)
+		switch time {
+		case sarama.OffsetOldest:
+			return int64(partitionId) * 10, nil
+		case sarama.OffsetNewest:
+			return int64(partitionId) * 100, nil
+		case 5000:
+			if partitionId == 1 {
+				return 42, nil
+			}
+			return -1, nil
+		}
+		return 0, errors.New("unexpected time")
+	}
+	c := NewClient(&MockHttpClient{}, &mk, nil)
+
+	offsets, err := c.GetTopicOffsets("topic-1", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &TopicOffsets{
+			Topic: "topic-1",
+			Partitions: []PartitionOffsets{
+				{Partition: 1, Earliest: 10, Latest: 100, Count: 90},
+				{Partition: 2, Earliest: 20, Latest: 200, Count: 180},
+			},
+			Count: 270,
+		}, offsets)
+		assert.Equal(t, map[int32]int64{1: 90, 2: 0}, offsets.Lag(map[int32]int64{1: 5, 2: 250}))
+		assert.Equal(t, map[int32]int64{1: 50, 2: 180}, offsets.Lag(map[int32]int64{1: 50}))
+	}
+
+	at := time.Unix(5, 0)
+	offsets, err = c.GetTopicOffsets("topic-1", &at)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(42), *offsets.Partitions[0].AtTime)
+		assert.Equal(t, int64(200), *offsets.Partitions[1].AtTime)
+	}
+
+	_, err = NewClient(&MockHttpClient{}, nil, nil).GetTopicOffsets("topic-1", nil)
+	assert.Equal(t, errors.New("GetTopicOffsets requires a kafka client"), err)
+}
@@ -0,0 +1,185 @@
+package confluent
+
+import (
+	"crypto/tls"
+	"errors"
+
+	"github.com/Shopify/sarama"
+)
+
+// Option configures the client built by New
+type Option func(o *options)
+
+type options struct {
+	baseUrl   string
+	username  string
+	password  string
+	userAgent string
+	tlsConfig *tls.Config
+	login     bool
+	seed      int64
+
+	httpClient  HttpClient
+	kafkaConfig *Config
+}
+
+// WithBaseUrl sets the endpoint of the Confluent API, e.g. https://localhost:8090
+func WithBaseUrl(baseUrl string) Option {
+	return func(o *options) {
+		o.baseUrl = baseUrl
+	}
+}
+
+// WithCredentials sets the user of the Confluent API
+func WithCredentials(username, password string) Option {
+	return func(o *options) {
+		o.username = username
+		o.password = password
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithHttpTLSConfig sets the TLS config used to connect to the Confluent API, see Config.NewTLSConfig. By default
+// the certificates and stores of the kafka config are used, when it has some.
+func WithHttpTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// WithHttpClient uses the given client for the Confluent API instead of a DefaultHttpClient,
+// the base url, credentials, user agent and TLS options are ignored
+func WithHttpClient(httpClient HttpClient) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithKafkaConfig connects to the brokers to use the features of the Kafka admin API.
+// The client only uses the Confluent API when the config has no bootstrap servers.
+func WithKafkaConfig(config *Config) Option {
+	return func(o *options) {
+		o.kafkaConfig = config
+	}
+}
+
+// WithClientConfig applies the configuration returned by LoadClientConfig
+func WithClientConfig(config *ClientConfig) Option {
+	return func(o *options) {
+		o.baseUrl = config.BaseUrl
+		o.username = config.Username
+		o.password = config.Password
+		o.kafkaConfig = &config.Kafka
+	}
+}
+
+// WithLogin logs in to the Metadata Service when the client is created, the bearer token is then used
+// instead of the credentials. The token is not renewed, see NewMDSTokenProvider for long-running clients.
+func WithLogin() Option {
+	return func(o *options) {
+		o.login = true
+	}
+}
+
+// WithPlacementSeed shuffles the brokers with the same load when choosing the brokers of new replicas, by default
+// they are chosen by broker id. The placement is the same for the same seed and cluster state.
+func WithPlacementSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = seed
+	}
+}
+
+// New builds the client of the Confluent API and, when bootstrap servers are configured, the kafka client
+// and cluster admin. Close releases them.
+func New(opts ...Option) (*Client, error) {
+	o := options{
+		userAgent: userAgent,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	httpClient := o.httpClient
+	if httpClient == nil {
+		if o.baseUrl == "" {
+			return nil, errors.New("No base url provided")
+		}
+		defaultHttpClient := NewDefaultHttpClient(o.baseUrl, o.username, o.password)
+		defaultHttpClient.UserAgent = o.userAgent
+		defaultHttpClient.TLSConfig = o.tlsConfig
+		httpClient = defaultHttpClient
+	}
+	defaultHttpClient, isDefaultHttpClient := httpClient.(*DefaultHttpClient)
+	if o.login && !isDefaultHttpClient {
+		return nil, errors.New("WithLogin requires the default http client")
+	}
+
+	c := NewClient(httpClient, nil, nil)
+	c.placementSeed = o.seed
+	if o.kafkaConfig != nil && o.kafkaConfig.BootstrapServers != nil && len(*o.kafkaConfig.BootstrapServers) != 0 {
+		kafkaClient, saramaClient, err := NewDefaultSaramaClient(o.kafkaConfig)
+		if err != nil {
+			return nil, err
+		}
+		admin, err := NewDefaultSaramaClusterAdmin(saramaClient)
+		if err != nil {
+			_ = kafkaClient.Close()
+			return nil, err
+		}
+		c.saramaClient = kafkaClient
+		c.saramaClusterAdmin = admin
+	}
+
+	// The REST client shares the TLS config, and the reloaded certificates, of the brokers
+	if o.httpClient == nil && o.tlsConfig == nil && o.kafkaConfig != nil && o.kafkaConfig.hasTLSMaterial() {
+		tlsConfig, err := o.kafkaConfig.NewTLSConfig()
+		if err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+		c.certReloader = o.kafkaConfig.certReloader
+		defaultHttpClient.TLSConfig = tlsConfig
+	}
+
+	if o.login {
+		token, err := c.Login()
+		if err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+		defaultHttpClient.Token = token
+	}
+	return c, nil
+}
+
+// Close releases the kafka client, the cluster admin and the idle connections to the Confluent API
+func (c *Client) Close() error {
+	errs := make([]error, 0)
+	if admin, ok := c.saramaClusterAdmin.(*DefaultSaramaClusterAdmin); ok {
+		if err := admin.Close(); err != nil && err != sarama.ErrClosedClient {
+			errs = append(errs, err)
+		}
+	}
+	if kafkaClient, ok := c.saramaClient.(*DefaultSaramaClient); ok {
+		// Also closed with the cluster admin, which shares the same sarama client
+		if err := kafkaClient.Close(); err != nil && err != sarama.ErrClosedClient {
+			errs = append(errs, err)
+		}
+	}
+	if c.certReloader != nil {
+		c.certReloader.Close()
+	}
+	if httpClient, ok := c.httpClient.(interface{ CloseIdleConnections() }); ok {
+		httpClient.CloseIdleConnections()
+	}
+
+	if len(errs) != 0 {
+		return errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package confluent
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMDS(t *testing.T) (*httptest.Server, *[]string) {
+	authorizations := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/security/1.0/authenticate":
+			_, _ = w.Write([]byte(`{"auth_token": "token-1", "token_type": "Bearer", "expires_in": 3600}`))
+		case "/security/1.0/roles":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, &authorizations
+}
+
+func TestOptions_NewRestOnly(t *testing.T) {
+	server, authorizations := newTestMDS(t)
+	defer server.Close()
+
+	c, err := New(WithBaseUrl(server.URL), WithCredentials("alice", "secret"), WithLogin(), WithKafkaConfig(&Config{}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Nil(t, c.saramaClient)
+	assert.Nil(t, c.saramaClusterAdmin)
+	assert.False(t, c.SupportsAPI(apiKeyDescribeUserScramCredentials, 0))
+
+	_, err = c.ListRoles()
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(*authorizations)) {
+		assert.Equal(t, "Basic YWxpY2U6c2VjcmV0", (*authorizations)[0])
+		assert.Equal(t, "Bearer token-1", (*authorizations)[1])
+	}
+
+	assert.NoError(t, c.Close())
+	assert.NoError(t, c.Close())
+}
+
+func TestOptions_NewWithKafka(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()),
+		"ApiVersionsRequest": sarama.NewMockWrapper(&sarama.ApiVersionsResponse{ApiKeys: apiVersionsUpTo(sarama.V2_7_0_0)}),
+	})
+
+	cc := &ClientConfig{
+		Kafka:   Config{BootstrapServers: &[]string{broker.Addr()}, Timeout: 10, KafkaVersion: "auto"},
+		BaseUrl: "https://localhost:8090",
+	}
+	c, err := New(WithClientConfig(cc), WithUserAgent("test-agent"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotNil(t, c.saramaClusterAdmin)
+	assert.True(t, c.SupportsAPI(apiKeyDescribeUserScramCredentials, 0))
+	assert.Equal(t, "test-agent", c.httpClient.(*DefaultHttpClient).UserAgent)
+
+	assert.NoError(t, c.Close())
+	assert.NoError(t, c.Close())
+}
+
+func TestOptions_NewHttpTLSFromKafkaConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	c, err := New(WithBaseUrl(server.URL), WithKafkaConfig(&Config{CACert: caCert}))
+	if assert.NoError(t, err) {
+		_, err = c.ListRoles()
+		assert.NoError(t, err)
+		assert.NoError(t, c.Close())
+	}
+
+	// The server is verified with the CA certificates of the kafka config
+	other := newTestCertificate(t, "ca", time.Now().Add(time.Hour), nil)
+	c, err = New(WithBaseUrl(server.URL), WithKafkaConfig(&Config{CACert: other.certPEM}))
+	if assert.NoError(t, err) {
+		_, err = c.ListRoles()
+		assert.Error(t, err)
+		assert.NoError(t, c.Close())
+	}
+
+	// An explicit TLS config is kept
+	c, err = New(WithBaseUrl(server.URL), WithKafkaConfig(&Config{CACert: other.certPEM}), WithHttpTLSConfig(server.Client().Transport.(*http.Transport).TLSClientConfig))
+	if assert.NoError(t, err) {
+		_, err = c.ListRoles()
+		assert.NoError(t, err)
+		assert.NoError(t, c.Close())
+	}
+}
+
+func TestOptions_NewErrors(t *testing.T) {
+	_, err := New()
+	assert.NotNil(t, err, "no base url")
+
+	_, err = New(WithBaseUrl("https://localhost:8090"), WithKafkaConfig(&Config{BootstrapServers: &[]string{"localhost:9092"}}))
+	assert.NotNil(t, err, "invalid kafka config")
+
+	// The custom http client is rejected before logging in
+	mock := MockHttpClient{DoRequestFn: func(string, string, io.Reader) ([]byte, int, string, error) {
+		t.Fatal("the login should not be requested")
+		return nil, 0, "", nil
+	}}
+	_, err = New(WithHttpClient(&mock), WithLogin())
+	assert.EqualError(t, err, "WithLogin requires the default http client")
+}
@@ -1,6 +1,9 @@
 package confluent
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strconv"
+)
 
 type Partition struct {
 	ClusterID   string `json:"cluster_id"`
@@ -25,3 +28,36 @@ func (c *Client) GetTopicPartitions(clusterId, topicName string) ([]Partition, e
 	}
 	return body.Data, nil
 }
+
+type Replica struct {
+	ClusterID   string `json:"cluster_id"`
+	TopicName   string `json:"topic_name"`
+	PartitionId int    `json:"partition_id"`
+	BrokerID    int32  `json:"broker_id"`
+	IsLeader    bool   `json:"is_leader"`
+	IsInSync    bool   `json:"is_in_sync"`
+}
+
+// GetPartitionReplicas returns the replicas of the partition, in the order of the replica assignment
+// @ref https://docs.confluent.io/platform/current/kafka-rest/api.html#get--clusters-cluster_id-topics-topic_name-partitions-partition_id-replicas
+func (c *Client) GetPartitionReplicas(clusterId, topicName string, partitionId int) ([]Replica, error) {
+	u := partitionUri(clusterId, topicName, partitionId) + "/replicas"
+	r, err := c.DoRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body := struct {
+		Data []Replica `json:"data"`
+	}{}
+
+	err = json.Unmarshal(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+func partitionUri(clusterId, topicName string, partitionId int) string {
+	return clusterUri + "/" + clusterId + "/topics/" + topicName + "/partitions/" + strconv.Itoa(partitionId)
+}
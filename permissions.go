@@ -0,0 +1,235 @@
+package confluent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	aclPermissionAllow = "ALLOW"
+	aclPermissionDeny  = "DENY"
+	patternLiteral     = "LITERAL"
+	patternPrefixed    = "PREFIXED"
+	roleScopeCluster   = "CLUSTER"
+	wildcardResource   = "*"
+	wildcardPrincipal  = "User:*"
+)
+
+// PermissionsReport merges the Kafka ACLs and the RBAC role bindings that apply to one principal
+type PermissionsReport struct {
+	ClusterId string
+	Principal string
+
+	// Acls matching the principal, either literally or through the "User:*" wildcard
+	Acls []Acl
+
+	// RoleBindings of the principal, resolved against the role definitions
+	RoleBindings []EffectiveRoleBinding
+}
+
+// EffectiveRoleBinding is a role bound to a principal together with what the role allows
+type EffectiveRoleBinding struct {
+	RoleName string
+
+	// ScopeType is "Cluster" when the binding applies to the whole cluster, "Resource" otherwise
+	ScopeType         string
+	ResourcePatterns  []ResourcePattern
+	AllowedOperations []AllowedOperation
+}
+
+// PermissionDecision explains whether an operation on a resource is allowed and why
+type PermissionDecision struct {
+	ResourceType string
+	ResourceName string
+	Operation    string
+	Allowed      bool
+
+	// DenyingAcls always win over any grant
+	DenyingAcls          []Acl
+	GrantingAcls         []Acl
+	GrantingRoleBindings []EffectiveRoleBinding
+	Reason               string
+}
+
+// EffectivePermissions builds the permissions report of the principal on the cluster
+// from the ACLs of the cluster, the role bindings of the principal and the role definitions.
+// Super users configured on the brokers are not visible through the APIs and are not reported.
+func (c *Client) EffectivePermissions(clusterId, principal string) (*PermissionsReport, error) {
+	acls, err := c.ListAcls(clusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	cDetails := ClusterDetails{
+		Clusters: Clusters{
+			KafkaCluster: clusterId,
+		},
+	}
+	bindings, err := c.LookupPrincipalResources(principal, cDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := c.ListRoles()
+	if err != nil {
+		return nil, err
+	}
+	roleByName := make(map[string]Role, len(roles))
+	for _, r := range roles {
+		roleByName[r.Name] = r
+	}
+
+	report := &PermissionsReport{
+		ClusterId: clusterId,
+		Principal: principal,
+	}
+	for _, acl := range acls {
+		if aclMatchesPrincipal(acl.Principal, principal) {
+			report.Acls = append(report.Acls, acl)
+		}
+	}
+	roleNames := make([]string, 0, len(bindings))
+	for roleName := range bindings {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+	for _, roleName := range roleNames {
+		patterns := bindings[roleName]
+		role, ok := roleByName[roleName]
+		if !ok {
+			return nil, fmt.Errorf("unknown role %s bound to %s", roleName, principal)
+		}
+		report.RoleBindings = append(report.RoleBindings, EffectiveRoleBinding{
+			RoleName:          roleName,
+			ScopeType:         role.AccessPolicy.ScopeType,
+			ResourcePatterns:  patterns,
+			AllowedOperations: role.AccessPolicy.AllowedOperations,
+		})
+	}
+
+	return report, nil
+}
+
+// Explain decides whether the operation on the resource is allowed, following the Kafka rules:
+// a matching DENY acl always wins, otherwise any matching ALLOW acl or role binding grants the access,
+// otherwise the access is denied.
+func (r *PermissionsReport) Explain(resourceType, resourceName, operation string) PermissionDecision {
+	d := PermissionDecision{
+		ResourceType: resourceType,
+		ResourceName: resourceName,
+		Operation:    operation,
+	}
+
+	for _, acl := range r.Acls {
+		if !sameName(acl.ResourceType, resourceType) || !patternMatches(acl.PatternType, acl.ResourceName, resourceName) {
+			continue
+		}
+		switch normalizeName(acl.Permission) {
+		case aclPermissionDeny:
+			// Unlike ALLOW, a DENY only matches the operation itself or ALL
+			if normalizeName(acl.Operation) == "ALL" || sameName(acl.Operation, operation) {
+				d.DenyingAcls = append(d.DenyingAcls, acl)
+			}
+		case aclPermissionAllow:
+			if operationImplies(acl.Operation, operation) {
+				d.GrantingAcls = append(d.GrantingAcls, acl)
+			}
+		}
+	}
+
+	for _, b := range r.RoleBindings {
+		if b.allows(resourceType, resourceName, operation) {
+			d.GrantingRoleBindings = append(d.GrantingRoleBindings, b)
+		}
+	}
+
+	switch {
+	case len(d.DenyingAcls) > 0:
+		d.Reason = "denied by ACL " + describeAcl(d.DenyingAcls[0])
+	case len(d.GrantingAcls) > 0:
+		d.Allowed = true
+		d.Reason = "allowed by ACL " + describeAcl(d.GrantingAcls[0])
+	case len(d.GrantingRoleBindings) > 0:
+		d.Allowed = true
+		d.Reason = "allowed by role " + d.GrantingRoleBindings[0].RoleName
+	default:
+		d.Reason = "no ACL or role binding grants " + operation + " on " + resourceType + ":" + resourceName
+	}
+
+	return d
+}
+
+func (b *EffectiveRoleBinding) allows(resourceType, resourceName, operation string) bool {
+	if !b.allowsOperation(resourceType, operation) {
+		return false
+	}
+	// Cluster-scoped roles apply to every resource of the cluster
+	if normalizeName(b.ScopeType) == roleScopeCluster {
+		return true
+	}
+	for _, p := range b.ResourcePatterns {
+		if sameName(p.ResourceType, resourceType) && patternMatches(p.PatternType, p.Name, resourceName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *EffectiveRoleBinding) allowsOperation(resourceType, operation string) bool {
+	for _, a := range b.AllowedOperations {
+		if !sameName(a.ResourceType, resourceType) {
+			continue
+		}
+		for _, op := range a.Operations {
+			if operationImplies(op, operation) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func aclMatchesPrincipal(aclPrincipal, principal string) bool {
+	return aclPrincipal == principal || aclPrincipal == wildcardPrincipal
+}
+
+func patternMatches(patternType, patternName, resourceName string) bool {
+	switch normalizeName(patternType) {
+	case patternPrefixed:
+		return strings.HasPrefix(resourceName, patternName)
+	case patternLiteral, "":
+		return patternName == wildcardResource || patternName == resourceName
+	}
+	return false
+}
+
+// operationImplies reports whether being granted the operation "granted" also grants "requested".
+// Kafka implies DESCRIBE from READ, WRITE, DELETE and ALTER, and DESCRIBE_CONFIGS from ALTER_CONFIGS.
+func operationImplies(granted, requested string) bool {
+	g := normalizeName(granted)
+	r := normalizeName(requested)
+	if g == "ALL" || g == r {
+		return true
+	}
+	switch r {
+	case "DESCRIBE":
+		return g == "READ" || g == "WRITE" || g == "DELETE" || g == "ALTER"
+	case "DESCRIBECONFIGS":
+		return g == "ALTERCONFIGS"
+	}
+	return false
+}
+
+// normalizeName makes ACL names (DESCRIBE_CONFIGS, TRANSACTIONAL_ID) comparable to RBAC names (DescribeConfigs, TransactionalId)
+func normalizeName(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(s, "_", ""))
+}
+
+func sameName(a, b string) bool {
+	return normalizeName(a) == normalizeName(b)
+}
+
+func describeAcl(acl Acl) string {
+	return fmt.Sprintf("%s %s on %s:%s:%s for %s@%s", acl.Permission, acl.Operation, acl.ResourceType, acl.PatternType, acl.ResourceName, acl.Principal, acl.Host)
+}
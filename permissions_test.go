@@ -0,0 +1,156 @@
+package confluent
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockPermissionsHttpClient(t *testing.T) *MockHttpClient {
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		switch uri {
+		case "/clusters/cluster-1/acls":
+			assert.Equal(t, http.MethodGet, method)
+			return []byte(`
+			{
+				"data": [
+					{
+						"cluster_id": "cluster-1",
+						"resource_type": "TOPIC",
+						"resource_name": "orders-",
+						"pattern_type": "PREFIXED",
+						"principal": "User:alice",
+						"host": "*",
+						"operation": "WRITE",
+						"permission": "ALLOW"
+					},
+					{
+						"cluster_id": "cluster-1",
+						"resource_type": "TOPIC",
+						"resource_name": "orders-secret",
+						"pattern_type": "LITERAL",
+						"principal": "User:*",
+						"host": "*",
+						"operation": "ALL",
+						"permission": "DENY"
+					},
+					{
+						"cluster_id": "cluster-1",
+						"resource_type": "TOPIC",
+						"resource_name": "*",
+						"pattern_type": "LITERAL",
+						"principal": "User:bob",
+						"host": "*",
+						"operation": "ALL",
+						"permission": "ALLOW"
+					}
+				]
+			}`), 200, "200 OK", nil
+		case "/security/1.0/lookup/principal/User:alice/resources":
+			assert.Equal(t, http.MethodPost, method)
+			return []byte(`
+			{
+				"User:alice": {
+					"DeveloperRead": [
+						{"resourceType": "Topic", "name": "payments", "patternType": "LITERAL"}
+					],
+					"Operator": []
+				}
+			}`), 200, "200 OK", nil
+		case "/security/1.0/roles":
+			assert.Equal(t, http.MethodGet, method)
+			return []byte(`
+			[
+				{
+					"name": "DeveloperRead",
+					"accessPolicy": {
+						"scopeType": "Resource",
+						"allowedOperations": [
+							{"resourceType": "Topic", "operations": ["Read", "Describe"]}
+						]
+					}
+				},
+				{
+					"name": "Operator",
+					"accessPolicy": {
+						"scopeType": "Cluster",
+						"allowedOperations": [
+							{"resourceType": "Group", "operations": ["Describe"]}
+						]
+					}
+				}
+			]`), 200, "200 OK", nil
+		}
+		t.Fatalf("unexpected uri %s", uri)
+		return nil, 0, "", nil
+	}
+	return &mock
+}
+
+func TestPermissions_EffectivePermissionsSuccess(t *testing.T) {
+	mock := mockPermissionsHttpClient(t)
+	mk := MockKafkaClient{}
+	clusterAdmin, _ := mk.NewSaramaClusterAdmin()
+	c := NewClient(mock, &mk, clusterAdmin)
+
+	report, err := c.EffectivePermissions(clusterId, "User:alice")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, len(report.Acls))
+		assert.Equal(t, 2, len(report.RoleBindings))
+		assert.Equal(t, "DeveloperRead", report.RoleBindings[0].RoleName)
+		assert.Equal(t, "Operator", report.RoleBindings[1].RoleName)
+
+		d := report.Explain("TOPIC", "orders-eu", "WRITE")
+		assert.True(t, d.Allowed)
+		assert.Equal(t, 1, len(d.GrantingAcls))
+
+		d = report.Explain("Topic", "orders-eu", "Describe")
+		assert.True(t, d.Allowed, "WRITE implies DESCRIBE")
+
+		d = report.Explain("TOPIC", "orders-secret", "WRITE")
+		assert.False(t, d.Allowed)
+		assert.Equal(t, "denied by ACL DENY ALL on TOPIC:LITERAL:orders-secret for User:*@*", d.Reason)
+
+		d = report.Explain("TOPIC", "payments", "READ")
+		assert.True(t, d.Allowed)
+		assert.Equal(t, "allowed by role DeveloperRead", d.Reason)
+
+		d = report.Explain("GROUP", "any-group", "DESCRIBE")
+		assert.True(t, d.Allowed)
+		assert.Equal(t, "allowed by role Operator", d.Reason)
+
+		d = report.Explain("TOPIC", "payments", "WRITE")
+		assert.False(t, d.Allowed)
+		assert.Empty(t, d.GrantingRoleBindings)
+	}
+}
+
+func TestPermissions_EffectivePermissionsUnknownRole(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		switch uri {
+		case "/clusters/cluster-1/acls":
+			return []byte(`{"data": []}`), 200, "200 OK", nil
+		case "/security/1.0/lookup/principal/User:alice/resources":
+			return []byte(`{"User:alice": {"Custom": []}}`), 200, "200 OK", nil
+		}
+		return []byte(`[]`), 200, "200 OK", nil
+	}
+	clusterAdmin, _ := mk.NewSaramaClusterAdmin()
+	c := NewClient(&mock, &mk, clusterAdmin)
+
+	_, err := c.EffectivePermissions(clusterId, "User:alice")
+	assert.EqualError(t, err, "unknown role Custom bound to User:alice")
+}
+
+func TestPermissions_OperationImplies(t *testing.T) {
+	assert.True(t, operationImplies("ALL", "WRITE"))
+	assert.True(t, operationImplies("AlterConfigs", "DESCRIBE_CONFIGS"))
+	assert.True(t, operationImplies("READ", "DESCRIBE"))
+	assert.False(t, operationImplies("DESCRIBE", "READ"))
+	assert.False(t, operationImplies("READ", "WRITE"))
+}
@@ -0,0 +1,175 @@
+package confluent
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+)
+
+// brokerLoad is the rack and the number of replicas and preferred leaders hosted by a broker
+type brokerLoad struct {
+	id       int32
+	rack     string
+	replicas int
+	leaders  int
+	// offline is set for the brokers hosting replicas which are missing from the brokers of the metadata, e.g. a dead
+	// broker
+	offline bool
+}
+
+// replicaPlacer chooses the brokers of new replicas. It prefers the brokers in the racks with the fewest replicas of
+// the partition, then the brokers with the fewest replicas and the fewest leaders. The remaining ties are broken by
+// the order of the brokers, which is shuffled by a non-zero seed, so that the placement is deterministic given the
+// seed and the loads. The loads are updated with each placement to balance the replicas of the next partitions.
+type replicaPlacer struct {
+	brokers []*brokerLoad
+	byId    map[int32]*brokerLoad
+}
+
+func newReplicaPlacer(brokers []brokerLoad, seed int64) *replicaPlacer {
+	rp := &replicaPlacer{
+		brokers: make([]*brokerLoad, 0, len(brokers)),
+		byId:    make(map[int32]*brokerLoad, len(brokers)),
+	}
+	for i := range brokers {
+		b := brokers[i]
+		rp.brokers = append(rp.brokers, &b)
+		rp.byId[b.id] = &b
+	}
+
+	sort.Slice(rp.brokers, func(i, j int) bool { return rp.brokers[i].id < rp.brokers[j].id })
+	if seed != 0 {
+		r := rand.New(rand.NewSource(seed))
+		r.Shuffle(len(rp.brokers), func(i, j int) { rp.brokers[i], rp.brokers[j] = rp.brokers[j], rp.brokers[i] })
+	}
+	return rp
+}
+
+// place returns the replicas followed by count new replicas
+func (rp *replicaPlacer) place(replicas []int32, count int) ([]int32, error) {
+	used := make(map[int32]bool, len(replicas)+count)
+	racks := make(map[string]int)
+	for _, r := range replicas {
+		used[r] = true
+		if b, ok := rp.byId[r]; ok && b.rack != "" {
+			racks[b.rack]++
+		}
+	}
+
+	available := 0
+	for _, b := range rp.brokers {
+		if !used[b.id] {
+			available++
+		}
+	}
+	if available < count {
+		return nil, errors.New("not enough brokers")
+	}
+
+	newReplicas := make([]int32, len(replicas), len(replicas)+count)
+	copy(newReplicas, replicas)
+	for i := 0; i < count; i++ {
+		var best *brokerLoad
+		for _, b := range rp.brokers {
+			if used[b.id] {
+				continue
+			}
+			if best == nil || rp.better(b, best, racks) {
+				best = b
+			}
+		}
+
+		used[best.id] = true
+		if best.rack != "" {
+			racks[best.rack]++
+		}
+		best.replicas++
+		newReplicas = append(newReplicas, best.id)
+	}
+	return newReplicas, nil
+}
+
+// release removes the replicas from the loads of their brokers
+func (rp *replicaPlacer) release(replicas []int32) {
+	for _, r := range replicas {
+		if b, ok := rp.byId[r]; ok {
+			b.replicas--
+		}
+	}
+}
+
+func (rp *replicaPlacer) better(a, b *brokerLoad, racks map[string]int) bool {
+	if racks[a.rack] != racks[b.rack] && a.rack != "" && b.rack != "" {
+		return racks[a.rack] < racks[b.rack]
+	}
+	if a.replicas != b.replicas {
+		return a.replicas < b.replicas
+	}
+	return a.leaders < b.leaders
+}
+
+// brokerLoads returns the racks and the loads of the live brokers known by the kafka client, the leaders are the
+// preferred leaders of the partitions
+func (c *Client) brokerLoads() ([]brokerLoad, error) {
+	loads, _, err := c.clusterReplicas()
+	if err != nil {
+		return nil, err
+	}
+	live := make([]brokerLoad, 0, len(loads))
+	for _, l := range loads {
+		if !l.offline {
+			live = append(live, l)
+		}
+	}
+	return live, nil
+}
+
+// brokerLoadsRest returns the racks and the loads of the brokers of the cluster through the REST API
+func (c *Client) brokerLoadsRest(clusterId string) ([]brokerLoad, error) {
+	brokers, err := c.ListBrokers(clusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	loads := make(map[int32]*brokerLoad, len(brokers))
+	for _, b := range brokers {
+		load := &brokerLoad{id: b.BrokerID}
+		if b.Rack != nil {
+			load.rack = *b.Rack
+		}
+		replicas, err := c.ListBrokerReplicas(clusterId, b.BrokerID)
+		if err != nil {
+			return nil, err
+		}
+		load.replicas = len(replicas)
+		for _, r := range replicas {
+			if r.IsLeader {
+				load.leaders++
+			}
+		}
+		loads[b.BrokerID] = load
+	}
+	return sortedLoads(loads), nil
+}
+
+func countReplicas(loads map[int32]*brokerLoad, replicas []int32) {
+	for i, r := range replicas {
+		load, ok := loads[r]
+		if !ok {
+			continue
+		}
+		load.replicas++
+		if i == 0 {
+			load.leaders++
+		}
+	}
+}
+
+func sortedLoads(loads map[int32]*brokerLoad) []brokerLoad {
+	sorted := make([]brokerLoad, 0, len(loads))
+	for _, l := range loads {
+		sorted = append(sorted, *l)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].id < sorted[j].id })
+	return sorted
+}
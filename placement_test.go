@@ -0,0 +1,123 @@
+package confluent
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlacement_PrefersOtherRacks(t *testing.T) {
+	placer := newReplicaPlacer([]brokerLoad{
+		{id: 1, rack: "a"},
+		{id: 2, rack: "a"},
+		{id: 3, rack: "b", replicas: 10},
+		{id: 4, rack: "c", replicas: 5},
+	}, 0)
+
+	replicas, err := placer.place([]int32{1}, 2)
+	if assert.NoError(t, err) {
+		// The brokers of the racks b and c are more loaded but the rack a already has a replica
+		assert.Equal(t, []int32{1, 4, 3}, replicas)
+	}
+
+	replicas, err = placer.place([]int32{1, 4, 3}, 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int32{1, 4, 3, 2}, replicas)
+	}
+}
+
+func TestPlacement_BalancesLoads(t *testing.T) {
+	placer := newReplicaPlacer([]brokerLoad{
+		{id: 1, replicas: 2, leaders: 2},
+		{id: 2, replicas: 1, leaders: 1},
+		{id: 3, replicas: 1},
+		{id: 4, replicas: 2},
+	}, 0)
+
+	assignment := make([][]int32, 0)
+	for _, current := range [][]int32{{1}, {2}, {1}} {
+		replicas, err := placer.place(current, 1)
+		if assert.NoError(t, err) {
+			assignment = append(assignment, replicas)
+		}
+	}
+	// The brokers 3 and 4 have the same load for the partition 1 but 3 comes first
+	assert.Equal(t, [][]int32{{1, 3}, {2, 3}, {1, 2}}, assignment)
+}
+
+func TestPlacement_DeterministicGivenSeed(t *testing.T) {
+	loads := []brokerLoad{{id: 1}, {id: 2}, {id: 3}, {id: 4}, {id: 5}, {id: 6}}
+	place := func(seed int64) [][]int32 {
+		placer := newReplicaPlacer(loads, seed)
+		assignment := make([][]int32, 0)
+		for p := int32(0); p < 6; p++ {
+			replicas, err := placer.place([]int32{p%6 + 1}, 2)
+			assert.NoError(t, err)
+			assignment = append(assignment, replicas)
+		}
+		return assignment
+	}
+
+	assert.Equal(t, place(42), place(42))
+	assert.Equal(t, []int32{1, 2, 3}, place(0)[0])
+	assert.NotEqual(t, place(0), place(42))
+}
+
+func TestPlacement_BrokerLoads(t *testing.T) {
+	brokers := []*sarama.Broker{sarama.NewBroker("broker-1:9092"), sarama.NewBroker("broker-2:9092"), sarama.NewBroker("broker-3:9092")}
+	mk := MockKafkaClient{
+		BrokersExpected: brokers,
+		TopicsExpected:  []string{"topic-1", "topic-2"},
+	}
+	mk.IDFn = func(broker *sarama.Broker) int32 {
+		return int32(broker.Addr()[7] - '0')
+	}
+	mk.RackFn = func(broker *sarama.Broker) string {
+		if broker.Addr() == "broker-3:9092" {
+			return "b"
+		}
+		return "a"
+	}
+	mk.ReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		if topic == "topic-1" {
+			return []int32{partitionId, 3}, nil
+		}
+		return []int32{3}, nil
+	}
+	c := NewClient(&MockHttpClient{}, &mk, &MockKafkaAdmin{})
+
+	loads, err := c.brokerLoads()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []brokerLoad{
+			{id: 1, rack: "a", replicas: 1, leaders: 1},
+			{id: 2, rack: "a", replicas: 1, leaders: 1},
+			{id: 3, rack: "b", replicas: 4, leaders: 2},
+		}, loads)
+	}
+}
+
+func TestPlacement_BrokerLoadsRest(t *testing.T) {
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		switch uri {
+		case "/kafka/v3/clusters/cluster-1/brokers":
+			return []byte(`{"data": [{"broker_id": 1, "rack": "a"}, {"broker_id": 2, "rack": null}]}`), 200, "200 OK", nil
+		case "/kafka/v3/clusters/cluster-1/brokers/1/partition-replicas":
+			return []byte(`{"data": [
+				{"topic_name": "topic-1", "partition_id": 0, "broker_id": 1, "is_leader": true},
+				{"topic_name": "topic-1", "partition_id": 1, "broker_id": 1, "is_leader": false}
+			]}`), 200, "200 OK", nil
+		}
+		assert.True(t, strings.HasPrefix(uri, "/kafka/v3/clusters/cluster-1/brokers/2/"), "Unexpected uri %s", uri)
+		return []byte(`{"data": []}`), 200, "200 OK", nil
+	}
+	c := NewClient(&mock, nil, nil)
+
+	loads, err := c.brokerLoadsRest(clusterId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []brokerLoad{{id: 1, rack: "a", replicas: 2, leaders: 1}, {id: 2}}, loads)
+	}
+}
@@ -0,0 +1,184 @@
+package confluent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// RecordFormat is the format of the key or the value of a produced record
+type RecordFormat string
+
+const (
+	RecordFormatJSON   RecordFormat = "JSON"
+	RecordFormatBinary RecordFormat = "BINARY"
+	RecordFormatString RecordFormat = "STRING"
+	// The schema formats serialize the data with a schema of the schema registry, given by id, subject and version,
+	// or by the raw schema
+	RecordFormatAvro       RecordFormat = "AVRO"
+	RecordFormatJSONSchema RecordFormat = "JSONSCHEMA"
+	RecordFormatProtobuf   RecordFormat = "PROTOBUF"
+)
+
+// RecordData is the key or the value of a produced record. The format is omitted to use the format of the schema
+// given by id or by subject.
+type RecordData struct {
+	Type RecordFormat `json:"type,omitempty"`
+	// Data is serialized to JSON, a []byte is encoded in base64 as the binary format requires
+	Data interface{} `json:"data"`
+
+	SchemaID *int `json:"schema_id,omitempty"`
+	// Subject and SchemaVersion select a schema of the subject, the latest when SchemaVersion is nil
+	Subject       string `json:"subject,omitempty"`
+	SchemaVersion *int   `json:"schema_version,omitempty"`
+	// SubjectNameStrategy is TOPIC_NAME, RECORD_NAME or TOPIC_RECORD_NAME
+	SubjectNameStrategy string `json:"subject_name_strategy,omitempty"`
+	// Schema is the raw schema of the data, registered if needed
+	Schema string `json:"schema,omitempty"`
+}
+
+// JSONData returns the data in the JSON format
+func JSONData(data interface{}) *RecordData {
+	return &RecordData{Type: RecordFormatJSON, Data: data}
+}
+
+// BinaryData returns the bytes in the binary format
+func BinaryData(data []byte) *RecordData {
+	return &RecordData{Type: RecordFormatBinary, Data: data}
+}
+
+// StringData returns the string in the string format
+func StringData(data string) *RecordData {
+	return &RecordData{Type: RecordFormatString, Data: data}
+}
+
+// SchemaData returns the data serialized with the schema of the schema registry
+func SchemaData(schemaId int, data interface{}) *RecordData {
+	return &RecordData{Data: data, SchemaID: &schemaId}
+}
+
+// RecordHeader is a header of a record, its value is encoded in base64
+type RecordHeader struct {
+	Name  string `json:"name"`
+	Value []byte `json:"value"`
+}
+
+// ProduceRecord is a record to produce, to the partition of the key or to any partition when PartitionID is nil
+type ProduceRecord struct {
+	PartitionID *int32         `json:"partition_id,omitempty"`
+	Headers     []RecordHeader `json:"headers,omitempty"`
+	Key         *RecordData    `json:"key,omitempty"`
+	Value       *RecordData    `json:"value,omitempty"`
+	// Timestamp defaults to the time of the production
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// RecordDataSize is the format and the size in bytes of the serialized key or value of a produced record
+type RecordDataSize struct {
+	Type string `json:"type"`
+	Size int    `json:"size"`
+}
+
+// ProduceResult is the acknowledgement of a produced record. ErrorCode is an HTTP status, 200 unless the record was
+// not produced.
+type ProduceResult struct {
+	ErrorCode   int             `json:"error_code"`
+	Message     string          `json:"message,omitempty"`
+	ClusterID   string          `json:"cluster_id,omitempty"`
+	TopicName   string          `json:"topic_name,omitempty"`
+	PartitionID int32           `json:"partition_id"`
+	Offset      int64           `json:"offset"`
+	Timestamp   *time.Time      `json:"timestamp,omitempty"`
+	Key         *RecordDataSize `json:"key,omitempty"`
+	Value       *RecordDataSize `json:"value,omitempty"`
+}
+
+// ProduceRecords produces the records to the topic, one request per record. It stops at the first record which is
+// not produced and returns the results of the records produced before it.
+// @ref https://docs.confluent.io/platform/current/kafka-rest/api.html#post--clusters-cluster_id-topics-topic_name-records
+func (c *Client) ProduceRecords(clusterId, topicName string, records []ProduceRecord) ([]ProduceResult, error) {
+	results := make([]ProduceResult, 0, len(records))
+	for i, record := range records {
+		payloadBuf := new(bytes.Buffer)
+		if err := json.NewEncoder(payloadBuf).Encode(record); err != nil {
+			return results, fmt.Errorf("record %d: %w", i, err)
+		}
+
+		r, err := c.DoRequest(http.MethodPost, recordsUri(clusterId, topicName), payloadBuf)
+		if err != nil {
+			return results, fmt.Errorf("record %d: %w", i, err)
+		}
+		var result ProduceResult
+		if err := json.Unmarshal(r, &result); err != nil {
+			return results, fmt.Errorf("record %d: %w", i, err)
+		}
+		if result.ErrorCode != 0 && result.ErrorCode != http.StatusOK {
+			return results, fmt.Errorf("record %d: %s", i, result.Message)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ProduceRecordsStream produces the records to the topic in the streaming mode, all the records are sent in one
+// request and acknowledged one by one. It returns the result of every record, the error lists the records which are
+// not produced.
+func (c *Client) ProduceRecordsStream(clusterId, topicName string, records []ProduceRecord) ([]ProduceResult, error) {
+	if len(records) == 0 {
+		return []ProduceResult{}, nil
+	}
+
+	// The records are written while they are sent, the request is chunked
+	reader, writer := io.Pipe()
+	go func() {
+		encoder := json.NewEncoder(writer)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				_ = writer.CloseWithError(err)
+				return
+			}
+		}
+		_ = writer.Close()
+	}()
+
+	r, err := c.DoRequest(http.MethodPost, recordsUri(clusterId, topicName), reader)
+	// Unblock the writer when the request failed before reading all the records
+	_ = reader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ProduceResult, 0, len(records))
+	decoder := json.NewDecoder(bytes.NewReader(r))
+	for decoder.More() {
+		var result ProduceResult
+		if err := decoder.Decode(&result); err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	if len(results) != len(records) {
+		return results, fmt.Errorf("%d records acknowledged out of %d", len(results), len(records))
+	}
+
+	errs := make([]error, 0)
+	for i, result := range results {
+		if result.ErrorCode != 0 && result.ErrorCode != http.StatusOK {
+			errs = append(errs, fmt.Errorf("record %d: %s", i, result.Message))
+		}
+	}
+	if len(errs) != 0 {
+		return results, errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
+	}
+	return results, nil
+}
+
+func recordsUri(clusterId, topicName string) string {
+	return clusterUri + "/" + clusterId + "/topics/" + topicName + "/records"
+}
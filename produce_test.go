@@ -0,0 +1,105 @@
+package confluent
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProduce_ProduceRecords(t *testing.T) {
+	requests := make([]string, 0)
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodPost, method)
+		assert.Equal(t, "/kafka/v3/clusters/cluster-1/topics/topic-1/records", uri)
+		b, _ := ioutil.ReadAll(reqBody)
+		requests = append(requests, string(b))
+		if len(requests) == 3 {
+			return []byte(`{"error_code": 400, "message": "Bad Request: Unexpected character"}`), 400, "400 Bad Request", nil
+		}
+		return []byte(`{"error_code": 200, "cluster_id": "cluster-1", "topic_name": "topic-1", "partition_id": 1, "offset": 10,
+			"timestamp": "2021-10-01T10:00:00Z", "key": {"type": "BINARY", "size": 3}, "value": {"type": "JSON", "size": 9}}`), 200, "200 OK", nil
+	}
+	c := NewClient(&mock, nil, nil)
+
+	partition := int32(1)
+	timestamp := time.Date(2021, 10, 1, 10, 0, 0, 0, time.UTC)
+	results, err := c.ProduceRecords(clusterId, "topic-1", []ProduceRecord{
+		{
+			PartitionID: &partition,
+			Headers:     []RecordHeader{{Name: "trace", Value: []byte("abc")}},
+			Key:         BinaryData([]byte("key")),
+			Value:       JSONData(map[string]int{"count": 1}),
+			Timestamp:   &timestamp,
+		},
+		{Key: StringData("key"), Value: SchemaData(3, map[string]string{"name": "alice"})},
+		{Value: &RecordData{Type: RecordFormatAvro, Schema: `"string"`, Data: "alice"}},
+	})
+	assert.EqualError(t, err, "record 2: error with status: 400 Bad Request Bad Request: Unexpected character")
+	assert.Equal(t, []string{
+		`{"partition_id":1,"headers":[{"name":"trace","value":"YWJj"}],"key":{"type":"BINARY","data":"a2V5"},"value":{"type":"JSON","data":{"count":1}},"timestamp":"2021-10-01T10:00:00Z"}` + "\n",
+		`{"key":{"type":"STRING","data":"key"},"value":{"data":{"name":"alice"},"schema_id":3}}` + "\n",
+		`{"value":{"type":"AVRO","data":"alice","schema":"\"string\""}}` + "\n",
+	}, requests)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, ProduceResult{
+			ErrorCode:   200,
+			ClusterID:   clusterId,
+			TopicName:   "topic-1",
+			PartitionID: 1,
+			Offset:      10,
+			Timestamp:   &timestamp,
+			Key:         &RecordDataSize{Type: "BINARY", Size: 3},
+			Value:       &RecordDataSize{Type: "JSON", Size: 9},
+		}, results[0])
+	}
+}
+
+func TestProduce_ProduceRecordsStream(t *testing.T) {
+	var request string
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, "/kafka/v3/clusters/cluster-1/topics/topic-1/records", uri)
+		b, _ := ioutil.ReadAll(reqBody)
+		request = string(b)
+		return []byte(`{"error_code": 200, "partition_id": 0, "offset": 1}
+{"error_code": 404, "message": "This server does not host this topic-partition."}
+{"error_code": 200, "partition_id": 1, "offset": 7}`), 200, "200 OK", nil
+	}
+	c := NewClient(&mock, nil, nil)
+
+	partition := int32(5)
+	results, err := c.ProduceRecordsStream(clusterId, "topic-1", []ProduceRecord{
+		{Value: StringData("a")},
+		{PartitionID: &partition, Value: StringData("b")},
+		{Value: StringData("c")},
+	})
+	assert.Equal(t, errors.New("record 1: This server does not host this topic-partition.\n"), err)
+	assert.Equal(t, `{"value":{"type":"STRING","data":"a"}}
+{"partition_id":5,"value":{"type":"STRING","data":"b"}}
+{"value":{"type":"STRING","data":"c"}}
+`, request)
+	assert.Equal(t, []ProduceResult{
+		{ErrorCode: 200, PartitionID: 0, Offset: 1},
+		{ErrorCode: 404, Message: "This server does not host this topic-partition."},
+		{ErrorCode: 200, PartitionID: 1, Offset: 7},
+	}, results)
+
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		return []byte(`{"error_code": 200, "partition_id": 0, "offset": 1}`), 200, "200 OK", nil
+	}
+	_, err = c.ProduceRecordsStream(clusterId, "topic-1", []ProduceRecord{{Value: StringData("a")}, {Value: StringData("b")}})
+	assert.Equal(t, errors.New("1 records acknowledged out of 2"), err)
+
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		// The request fails without reading the records
+		return nil, 0, "", errors.New("connection refused")
+	}
+	_, err = c.ProduceRecordsStream(clusterId, "topic-1", []ProduceRecord{{Value: StringData("a")}})
+	assert.Equal(t, errors.New("connection refused"), err)
+}
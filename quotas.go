@@ -0,0 +1,291 @@
+package confluent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+// QuotaEntityType is the type of a component of a client quota entity
+type QuotaEntityType string
+
+const (
+	QuotaEntityUser     QuotaEntityType = "user"
+	QuotaEntityClientID QuotaEntityType = "client-id"
+)
+
+// QuotaKey is the name of a client quota
+type QuotaKey string
+
+const (
+	// ProducerByteRate is the number of bytes per second the clients can produce per broker
+	ProducerByteRate QuotaKey = "producer_byte_rate"
+	// ConsumerByteRate is the number of bytes per second the clients can fetch per broker
+	ConsumerByteRate QuotaKey = "consumer_byte_rate"
+	// RequestPercentage is the percentage of the time of the request handler and network threads of a broker
+	RequestPercentage QuotaKey = "request_percentage"
+	// ControllerMutationRate is the number of partitions per second the clients can create or delete
+	ControllerMutationRate QuotaKey = "controller_mutation_rate"
+)
+
+// QuotaEntityName is the name of the component of a client quota entity, or the default entity
+type QuotaEntityName struct {
+	Name    string
+	Default bool
+}
+
+func (n *QuotaEntityName) String() string {
+	if n.Default {
+		return "<default>"
+	}
+	return n.Name
+}
+
+// ClientQuotaEntity is the user, the client id or the user and client id the quotas apply to. A nil component is not
+// part of the entity.
+type ClientQuotaEntity struct {
+	User     *QuotaEntityName
+	ClientID *QuotaEntityName
+}
+
+// UserQuotaEntity returns the entity of the user
+func UserQuotaEntity(user string) ClientQuotaEntity {
+	return ClientQuotaEntity{User: &QuotaEntityName{Name: user}}
+}
+
+// DefaultUserQuotaEntity returns the entity of the users without quotas of their own
+func DefaultUserQuotaEntity() ClientQuotaEntity {
+	return ClientQuotaEntity{User: &QuotaEntityName{Default: true}}
+}
+
+// ClientIDQuotaEntity returns the entity of the client id
+func ClientIDQuotaEntity(clientId string) ClientQuotaEntity {
+	return ClientQuotaEntity{ClientID: &QuotaEntityName{Name: clientId}}
+}
+
+// DefaultClientIDQuotaEntity returns the entity of the client ids without quotas of their own
+func DefaultClientIDQuotaEntity() ClientQuotaEntity {
+	return ClientQuotaEntity{ClientID: &QuotaEntityName{Default: true}}
+}
+
+// UserClientIDQuotaEntity returns the entity of the client id of the user
+func UserClientIDQuotaEntity(user string, clientId string) ClientQuotaEntity {
+	return ClientQuotaEntity{User: &QuotaEntityName{Name: user}, ClientID: &QuotaEntityName{Name: clientId}}
+}
+
+// String returns the entity in the format of kafka-configs, e.g. "user=alice,client-id=<default>"
+func (e ClientQuotaEntity) String() string {
+	components := make([]string, 0, 2)
+	if e.User != nil {
+		components = append(components, string(QuotaEntityUser)+"="+e.User.String())
+	}
+	if e.ClientID != nil {
+		components = append(components, string(QuotaEntityClientID)+"="+e.ClientID.String())
+	}
+	return strings.Join(components, ",")
+}
+
+// ClientQuota is the quotas of an entity
+type ClientQuota struct {
+	Entity ClientQuotaEntity
+	Values map[QuotaKey]float64
+}
+
+// QuotaMatchType is how a filter component matches the component of the entities, as in the DescribeClientQuotas API
+type QuotaMatchType int8
+
+const (
+	// QuotaMatchExact matches the component with the name of the filter component
+	QuotaMatchExact QuotaMatchType = 0
+	// QuotaMatchDefault matches the default component
+	QuotaMatchDefault QuotaMatchType = 1
+	// QuotaMatchAny matches any component, including the default one
+	QuotaMatchAny QuotaMatchType = 2
+)
+
+type ClientQuotaFilterComponent struct {
+	EntityType QuotaEntityType
+	Match      QuotaMatchType
+	// Name is only used by QuotaMatchExact
+	Name string
+}
+
+// ClientQuotaFilter selects the entities with all the components of the filter. When Strict is set, the entities must
+// not have other components.
+type ClientQuotaFilter struct {
+	Components []ClientQuotaFilterComponent
+	Strict     bool
+}
+
+// DescribeClientQuotas returns the quotas of the entities matching the filter, sorted by entity. An empty filter
+// returns the quotas of all the entities.
+// @ref https://kafka.apache.org/protocol#The_Messages_DescribeClientQuotas
+func (c *Client) DescribeClientQuotas(filter ClientQuotaFilter) ([]ClientQuota, error) {
+	if err := c.requireAPI(apiKeyDescribeClientQuotas, 0, "DescribeClientQuotas"); err != nil {
+		return nil, err
+	}
+	for _, component := range filter.Components {
+		if err := validateQuotaEntityType(component.EntityType); err != nil {
+			return nil, err
+		}
+		if component.Match < QuotaMatchExact || component.Match > QuotaMatchAny {
+			return nil, fmt.Errorf("invalid match type %d", component.Match)
+		}
+	}
+
+	quotas, err := c.saramaClusterAdmin.DescribeClientQuotas(filter)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].Entity.String() < quotas[j].Entity.String() })
+	return quotas, nil
+}
+
+// DescribeEntityQuotas returns the quotas of the entity, which are empty when the entity has no quota of its own
+func (c *Client) DescribeEntityQuotas(entity ClientQuotaEntity) (map[QuotaKey]float64, error) {
+	if err := validateQuotaEntity(entity); err != nil {
+		return nil, err
+	}
+	filter := ClientQuotaFilter{Strict: true}
+	for _, component := range []struct {
+		entityType QuotaEntityType
+		name       *QuotaEntityName
+	}{{QuotaEntityUser, entity.User}, {QuotaEntityClientID, entity.ClientID}} {
+		if component.name == nil {
+			continue
+		}
+		if component.name.Default {
+			filter.Components = append(filter.Components, ClientQuotaFilterComponent{EntityType: component.entityType, Match: QuotaMatchDefault})
+		} else {
+			filter.Components = append(filter.Components, ClientQuotaFilterComponent{EntityType: component.entityType, Name: component.name.Name})
+		}
+	}
+
+	quotas, err := c.DescribeClientQuotas(filter)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[QuotaKey]float64)
+	for _, q := range quotas {
+		for k, v := range q.Values {
+			values[k] = v
+		}
+	}
+	return values, nil
+}
+
+// AlterClientQuotas sets the quotas of the entity, the other quotas of the entity are kept. With validateOnly, the
+// brokers only validate the quotas.
+// @ref https://kafka.apache.org/protocol#The_Messages_AlterClientQuotas
+func (c *Client) AlterClientQuotas(entity ClientQuotaEntity, values map[QuotaKey]float64, validateOnly bool) error {
+	if err := c.requireAPI(apiKeyAlterClientQuotas, 0, "AlterClientQuotas"); err != nil {
+		return err
+	}
+	if err := validateQuotaEntity(entity); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("no quota for %s", entity)
+	}
+	for k, v := range values {
+		if err := validateQuotaKey(k); err != nil {
+			return err
+		}
+		if v <= 0 {
+			return fmt.Errorf("invalid value %v of the quota %s, it must be positive", v, k)
+		}
+	}
+	return c.saramaClusterAdmin.AlterClientQuotas(entity, values, nil, validateOnly)
+}
+
+// DeleteClientQuotas removes the quotas of the entity, or all its quotas when keys is empty, so that the default
+// quotas apply to it
+func (c *Client) DeleteClientQuotas(entity ClientQuotaEntity, keys []QuotaKey) error {
+	if err := c.requireAPI(apiKeyAlterClientQuotas, 0, "AlterClientQuotas"); err != nil {
+		return err
+	}
+	if err := validateQuotaEntity(entity); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		values, err := c.DescribeEntityQuotas(entity)
+		if err != nil {
+			return err
+		}
+		for k := range values {
+			keys = append(keys, k)
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	}
+	for _, k := range keys {
+		if err := validateQuotaKey(k); err != nil {
+			return err
+		}
+	}
+	return c.saramaClusterAdmin.AlterClientQuotas(entity, nil, keys, false)
+}
+
+func validateQuotaEntity(entity ClientQuotaEntity) error {
+	if entity.User == nil && entity.ClientID == nil {
+		return errors.New("the quota entity requires a user or a client id")
+	}
+	return nil
+}
+
+func validateQuotaEntityType(entityType QuotaEntityType) error {
+	switch entityType {
+	case QuotaEntityUser, QuotaEntityClientID:
+		return nil
+	}
+	return fmt.Errorf("invalid quota entity type %q: can only be %q or %q", entityType, QuotaEntityUser, QuotaEntityClientID)
+}
+
+func validateQuotaKey(key QuotaKey) error {
+	switch key {
+	case ProducerByteRate, ConsumerByteRate, RequestPercentage, ControllerMutationRate:
+		return nil
+	}
+	return fmt.Errorf("unknown quota %q", key)
+}
+
+// saramaQuotaEntity returns the components of the entity, a default component has no name
+func saramaQuotaEntity(entity ClientQuotaEntity) []sarama.QuotaEntityComponent {
+	components := make([]sarama.QuotaEntityComponent, 0, 2)
+	for _, component := range []struct {
+		entityType QuotaEntityType
+		name       *QuotaEntityName
+	}{{QuotaEntityUser, entity.User}, {QuotaEntityClientID, entity.ClientID}} {
+		if component.name == nil {
+			continue
+		}
+		if component.name.Default {
+			components = append(components, sarama.QuotaEntityComponent{EntityType: sarama.QuotaEntityType(component.entityType), MatchType: sarama.QuotaMatchDefault})
+		} else {
+			components = append(components, sarama.QuotaEntityComponent{EntityType: sarama.QuotaEntityType(component.entityType), MatchType: sarama.QuotaMatchExact, Name: component.name.Name})
+		}
+	}
+	return components
+}
+
+// clientQuotaEntity returns false when the entity has a component other than the user and the client id, e.g. an ip
+func clientQuotaEntity(components []sarama.QuotaEntityComponent) (ClientQuotaEntity, bool) {
+	entity := ClientQuotaEntity{}
+	for _, component := range components {
+		name := &QuotaEntityName{Name: component.Name, Default: component.MatchType == sarama.QuotaMatchDefault}
+		switch QuotaEntityType(component.EntityType) {
+		case QuotaEntityUser:
+			entity.User = name
+		case QuotaEntityClientID:
+			entity.ClientID = name
+		default:
+			return entity, false
+		}
+	}
+	return entity, true
+}
@@ -0,0 +1,194 @@
+package confluent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotas_ClientQuotaEntity(t *testing.T) {
+	assert.Equal(t, "user=alice", UserQuotaEntity("alice").String())
+	assert.Equal(t, "user=<default>", DefaultUserQuotaEntity().String())
+	assert.Equal(t, "client-id=app", ClientIDQuotaEntity("app").String())
+	assert.Equal(t, "client-id=<default>", DefaultClientIDQuotaEntity().String())
+	assert.Equal(t, "user=alice,client-id=app", UserClientIDQuotaEntity("alice", "app").String())
+}
+
+func TestQuotas_DescribeClientQuotas(t *testing.T) {
+	admin := &MockKafkaAdmin{}
+	admin.DescribeClientQuotasFn = func(filter ClientQuotaFilter) ([]ClientQuota, error) {
+		if len(filter.Components) == 0 {
+			return []ClientQuota{
+				{Entity: UserClientIDQuotaEntity("alice", "app"), Values: map[QuotaKey]float64{RequestPercentage: 50}},
+				{Entity: DefaultUserQuotaEntity(), Values: map[QuotaKey]float64{ConsumerByteRate: 2048}},
+				{Entity: UserQuotaEntity("alice"), Values: map[QuotaKey]float64{ProducerByteRate: 1024}},
+			}, nil
+		}
+		assert.Equal(t, ClientQuotaFilter{Strict: true, Components: []ClientQuotaFilterComponent{
+			{EntityType: QuotaEntityUser, Match: QuotaMatchDefault},
+			{EntityType: QuotaEntityClientID, Name: "app"},
+		}}, filter)
+		return []ClientQuota{{Entity: ClientQuotaEntity{User: &QuotaEntityName{Default: true}, ClientID: &QuotaEntityName{Name: "app"}}, Values: map[QuotaKey]float64{ProducerByteRate: 4096}}}, nil
+	}
+	mk := MockKafkaClient{}
+	c := NewClient(&MockHttpClient{}, &mk, admin)
+
+	quotas, err := c.DescribeClientQuotas(ClientQuotaFilter{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []ClientQuota{
+			{Entity: DefaultUserQuotaEntity(), Values: map[QuotaKey]float64{ConsumerByteRate: 2048}},
+			{Entity: UserQuotaEntity("alice"), Values: map[QuotaKey]float64{ProducerByteRate: 1024}},
+			{Entity: UserClientIDQuotaEntity("alice", "app"), Values: map[QuotaKey]float64{RequestPercentage: 50}},
+		}, quotas)
+	}
+
+	entity := ClientQuotaEntity{User: &QuotaEntityName{Default: true}, ClientID: &QuotaEntityName{Name: "app"}}
+	values, err := c.DescribeEntityQuotas(entity)
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[QuotaKey]float64{ProducerByteRate: 4096}, values)
+	}
+
+	_, err = c.DescribeClientQuotas(ClientQuotaFilter{Components: []ClientQuotaFilterComponent{{EntityType: "ip"}}})
+	assert.Equal(t, errors.New(`invalid quota entity type "ip": can only be "user" or "client-id"`), err)
+
+	_, err = c.DescribeEntityQuotas(ClientQuotaEntity{})
+	assert.Equal(t, errors.New("the quota entity requires a user or a client id"), err)
+
+	mk.SupportedAPIs = map[int]int{}
+	_, err = c.DescribeClientQuotas(ClientQuotaFilter{})
+	assert.Equal(t, errors.New("DescribeClientQuotas v0 is not supported by all brokers of the cluster"), err)
+
+	mk.SupportedAPIs, mk.MockVersion = nil, sarama.V2_5_0_0
+	_, err = c.DescribeClientQuotas(ClientQuotaFilter{})
+	assert.EqualError(t, err, "DescribeClientQuotas v0 requires kafka 2.6.0 but the client is configured for kafka 2.5.0, set KafkaVersion >= 2.6.0 or auto")
+}
+
+func TestQuotas_AlterClientQuotas(t *testing.T) {
+	type alter struct {
+		entity       string
+		set          map[QuotaKey]float64
+		remove       []QuotaKey
+		validateOnly bool
+	}
+	alters := make([]alter, 0)
+	admin := &MockKafkaAdmin{}
+	admin.AlterClientQuotasFn = func(entity ClientQuotaEntity, set map[QuotaKey]float64, remove []QuotaKey, validateOnly bool) error {
+		alters = append(alters, alter{entity.String(), set, remove, validateOnly})
+		return nil
+	}
+	admin.DescribeClientQuotasFn = func(filter ClientQuotaFilter) ([]ClientQuota, error) {
+		return []ClientQuota{{Entity: DefaultClientIDQuotaEntity(), Values: map[QuotaKey]float64{ProducerByteRate: 1024, ConsumerByteRate: 2048}}}, nil
+	}
+	c := NewClient(&MockHttpClient{}, &MockKafkaClient{}, admin)
+
+	assert.NoError(t, c.AlterClientQuotas(UserQuotaEntity("alice"), map[QuotaKey]float64{ProducerByteRate: 1024}, true))
+	assert.NoError(t, c.DeleteClientQuotas(UserClientIDQuotaEntity("alice", "app"), []QuotaKey{RequestPercentage}))
+	assert.NoError(t, c.DeleteClientQuotas(DefaultClientIDQuotaEntity(), nil))
+	assert.Equal(t, []alter{
+		{entity: "user=alice", set: map[QuotaKey]float64{ProducerByteRate: 1024}, validateOnly: true},
+		{entity: "user=alice,client-id=app", remove: []QuotaKey{RequestPercentage}},
+		{entity: "client-id=<default>", remove: []QuotaKey{ConsumerByteRate, ProducerByteRate}},
+	}, alters)
+
+	err := c.AlterClientQuotas(UserQuotaEntity("alice"), map[QuotaKey]float64{"fetch_rate": 1}, false)
+	assert.Equal(t, errors.New(`unknown quota "fetch_rate"`), err)
+
+	err = c.AlterClientQuotas(UserQuotaEntity("alice"), map[QuotaKey]float64{ProducerByteRate: -1}, false)
+	assert.Equal(t, errors.New("invalid value -1 of the quota producer_byte_rate, it must be positive"), err)
+
+	err = c.AlterClientQuotas(UserQuotaEntity("alice"), nil, false)
+	assert.Equal(t, errors.New("no quota for user=alice"), err)
+
+	err = NewClient(&MockHttpClient{}, nil, nil).DeleteClientQuotas(UserQuotaEntity("alice"), nil)
+	assert.Equal(t, errors.New("AlterClientQuotas requires a kafka client"), err)
+}
+
+func TestQuotas_DefaultAdminClientQuotas(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	message := "Quota producer_byte_rate must be a long"
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()),
+		"DescribeClientQuotasRequest": sarama.NewMockWrapper(&sarama.DescribeClientQuotasResponse{
+			Entries: []sarama.DescribeClientQuotasEntry{
+				{
+					Entity: []sarama.QuotaEntityComponent{
+						{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Name: "alice"},
+						{EntityType: sarama.QuotaEntityClientID, MatchType: sarama.QuotaMatchDefault},
+					},
+					Values: map[string]float64{"producer_byte_rate": 1024},
+				},
+				// The ip entities are left out
+				{
+					Entity: []sarama.QuotaEntityComponent{{EntityType: sarama.QuotaEntityIP, MatchType: sarama.QuotaMatchExact, Name: "10.0.0.1"}},
+					Values: map[string]float64{"connection_creation_rate": 10},
+				},
+			},
+		}),
+		"AlterClientQuotasRequest": sarama.NewMockSequence(
+			sarama.NewMockWrapper(&sarama.AlterClientQuotasResponse{Entries: []sarama.AlterClientQuotasEntryResponse{{}}}),
+			sarama.NewMockWrapper(&sarama.AlterClientQuotasResponse{Entries: []sarama.AlterClientQuotasEntryResponse{
+				{ErrorCode: sarama.ErrInvalidRequest, ErrorMsg: &message},
+			}}),
+		),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_6_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer client.Close()
+	admin, err := NewDefaultSaramaClusterAdmin(client)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	quotas, err := admin.DescribeClientQuotas(ClientQuotaFilter{Components: []ClientQuotaFilterComponent{
+		{EntityType: QuotaEntityUser, Name: "alice"},
+		{EntityType: QuotaEntityClientID, Match: QuotaMatchAny},
+	}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []ClientQuota{{
+			Entity: ClientQuotaEntity{User: &QuotaEntityName{Name: "alice"}, ClientID: &QuotaEntityName{Default: true}},
+			Values: map[QuotaKey]float64{ProducerByteRate: 1024},
+		}}, quotas)
+	}
+
+	err = admin.AlterClientQuotas(DefaultUserQuotaEntity(), map[QuotaKey]float64{ProducerByteRate: 1024, ConsumerByteRate: 2048}, []QuotaKey{RequestPercentage}, false)
+	assert.NoError(t, err)
+
+	err = admin.AlterClientQuotas(DefaultUserQuotaEntity(), map[QuotaKey]float64{ProducerByteRate: 1.5}, nil, true)
+	assert.EqualError(t, err, "user=<default>: Quota producer_byte_rate must be a long\n")
+
+	requests := make([]*sarama.AlterClientQuotasRequest, 0)
+	for _, rr := range broker.History() {
+		switch req := rr.Request.(type) {
+		case *sarama.DescribeClientQuotasRequest:
+			assert.Equal(t, []sarama.QuotaFilterComponent{
+				{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Match: "alice"},
+				{EntityType: sarama.QuotaEntityClientID, MatchType: sarama.QuotaMatchAny},
+			}, req.Components)
+		case *sarama.AlterClientQuotasRequest:
+			requests = append(requests, req)
+		}
+	}
+	if assert.Len(t, requests, 2) {
+		assert.Equal(t, []sarama.AlterClientQuotasEntry{{
+			Entity: []sarama.QuotaEntityComponent{{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchDefault}},
+			Ops: []sarama.ClientQuotasOp{
+				{Key: "consumer_byte_rate", Value: 2048},
+				{Key: "producer_byte_rate", Value: 1024},
+				{Key: "request_percentage", Remove: true},
+			},
+		}}, requests[0].Entries)
+		assert.False(t, requests[0].ValidateOnly)
+		assert.True(t, requests[1].ValidateOnly)
+	}
+}
@@ -6,7 +6,9 @@ import (
 )
 
 const (
-	principalPath = "/security/1.0/principals/"
+	principalPath       = "/security/1.0/principals/"
+	rolesPath           = "/security/1.0/roles"
+	lookupPrincipalPath = "/security/1.0/lookup/principal/"
 )
 
 type ClusterDetails struct {
@@ -115,3 +117,65 @@ func (c *Client) OverwriteRoleBinding(principal, roleName string, uRoleBinding R
 	}
 	return nil
 }
+
+// Role is a predefined RBAC role with the operations it allows on each resource type
+type Role struct {
+	Name         string       `json:"name"`
+	AccessPolicy AccessPolicy `json:"accessPolicy"`
+}
+
+type AccessPolicy struct {
+	// ScopeType is "Cluster" for cluster-scoped roles or "Resource" for resource-scoped roles
+	ScopeType         string             `json:"scopeType"`
+	AllowedOperations []AllowedOperation `json:"allowedOperations"`
+}
+
+type AllowedOperation struct {
+	ResourceType string   `json:"resourceType"`
+	Operations   []string `json:"operations"`
+}
+
+// ListRoles returns the definitions of all predefined roles
+// @ref https://docs.confluent.io/platform/current/security/rbac/rbac-predefined-roles.html
+func (c *Client) ListRoles() ([]Role, error) {
+	r, err := c.DoRequest("GET", rolesPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []Role
+	err = json.Unmarshal(r, &roles)
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// LookupPrincipalResources returns all role bindings of the principal at the given scope/cluster,
+// grouped by role name. Cluster-scoped roles are returned with an empty list of resource patterns.
+func (c *Client) LookupPrincipalResources(principal string, cDetails ClusterDetails) (map[string][]ResourcePattern, error) {
+	u := lookupPrincipalPath + principal + "/resources"
+
+	payloadBuf := new(bytes.Buffer)
+	json.NewEncoder(payloadBuf).Encode(cDetails)
+
+	r, err := c.DoRequest("POST", u, payloadBuf)
+	if err != nil {
+		return nil, err
+	}
+
+	// The response is keyed by principal, then by role name
+	var body map[string]map[string][]ResourcePattern
+	err = json.Unmarshal(r, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings := make(map[string][]ResourcePattern)
+	for _, roles := range body {
+		for role, patterns := range roles {
+			bindings[role] = append(bindings[role], patterns...)
+		}
+	}
+	return bindings, nil
+}
@@ -0,0 +1,338 @@
+package confluent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const defaultReassignmentWaitInterval = 5 * time.Second
+
+// PartitionReassignment is the reassignment in progress of the replicas of a partition
+type PartitionReassignment struct {
+	ClusterID   string `json:"cluster_id,omitempty"`
+	TopicName   string `json:"topic_name"`
+	PartitionId int32  `json:"partition_id"`
+
+	// Replicas includes the adding and removing replicas, it is only known with the kafka client
+	Replicas         []int32 `json:"replicas,omitempty"`
+	AddingReplicas   []int32 `json:"adding_replicas"`
+	RemovingReplicas []int32 `json:"removing_replicas"`
+}
+
+// ReassignmentProgress is reported to ReassignmentWaitOptions.Progress after each check of the reassignments
+type ReassignmentProgress struct {
+	// Total is the number of partitions seen in reassignment since the wait started
+	Total     int
+	Completed int
+	Pending   []PartitionReassignment
+}
+
+type ReassignmentWaitOptions struct {
+	// Interval between two checks of the reassignments, defaults to 5 seconds
+	Interval time.Duration
+	// Timeout stops the wait with an error, there is no timeout when 0
+	Timeout  time.Duration
+	Progress func(progress ReassignmentProgress)
+}
+
+// ListPartitionReassignments returns the reassignments in progress of the topic, or of all the topics of the
+// cluster when topicName is empty. Without kafka client, the reassignments are listed through the REST API.
+func (c *Client) ListPartitionReassignments(clusterId, topicName string) ([]PartitionReassignment, error) {
+	if c.saramaClient == nil || c.saramaClusterAdmin == nil {
+		return c.listPartitionReassignmentsRest(clusterId, topicName)
+	}
+	if err := c.requireAPI(apiKeyListPartitionReassignments, 0, "ListPartitionReassignments"); err != nil {
+		return nil, err
+	}
+	if err := c.saramaClient.RefreshMetadata(); err != nil {
+		return nil, err
+	}
+
+	topics := []string{topicName}
+	if topicName == "" {
+		var err error
+		if topics, err = c.saramaClient.Topics(); err != nil {
+			return nil, err
+		}
+	}
+
+	reassignments := make([]PartitionReassignment, 0)
+	for _, topic := range topics {
+		partitions, err := c.saramaClient.Partitions(topic)
+		if err != nil {
+			return nil, err
+		}
+		statusMap, err := c.saramaClusterAdmin.ListPartitionReassignments(topic, partitions)
+		if err != nil {
+			return nil, err
+		}
+		for partitionId, status := range statusMap[topic] {
+			reassignments = append(reassignments, PartitionReassignment{
+				ClusterID:        clusterId,
+				TopicName:        topic,
+				PartitionId:      partitionId,
+				Replicas:         status.Replicas,
+				AddingReplicas:   status.AddingReplicas,
+				RemovingReplicas: status.RemovingReplicas,
+			})
+		}
+	}
+	sortReassignments(reassignments)
+	return reassignments, nil
+}
+
+// @ref https://docs.confluent.io/platform/current/kafka-rest/api.html#get--clusters-cluster_id-topics---partitions---reassignment
+func (c *Client) listPartitionReassignmentsRest(clusterId, topicName string) ([]PartitionReassignment, error) {
+	if clusterId == "" {
+		return nil, errors.New("ClusterID is required to list the reassignments without kafka client")
+	}
+	topic := topicName
+	if topic == "" {
+		topic = "-"
+	}
+
+	u := clusterUri + "/" + clusterId + "/topics/" + topic + "/partitions/-/reassignment"
+	r, err := c.DoRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body := struct {
+		Data []PartitionReassignment `json:"data"`
+	}{}
+	err = json.Unmarshal(r, &body)
+	if err != nil {
+		return nil, err
+	}
+	reassignments := body.Data
+	if reassignments == nil {
+		reassignments = make([]PartitionReassignment, 0)
+	}
+	sortReassignments(reassignments)
+	return reassignments, nil
+}
+
+// AlterPartitionReassignments reassigns the partitions of the topic to the replicas of the assignment, indexed by
+// partition id. The other partitions of the topic are left untouched. The first replica is the preferred leader.
+// It requires a kafka client as the REST API can only list the reassignments.
+func (c *Client) AlterPartitionReassignments(clusterId, topicName string, assignment map[int32][]int32) error {
+	if err := validateReassignment(assignment); err != nil {
+		return err
+	}
+	if c.saramaClient == nil || c.saramaClusterAdmin == nil {
+		return errors.New("AlterPartitionReassignments requires a kafka client")
+	}
+
+	if err := c.requireAPI(apiKeyAlterPartitionReassignments, 0, "AlterPartitionReassignments"); err != nil {
+		return err
+	}
+	fullAssignment, err := c.completeAssignment(topicName, assignment)
+	if err != nil {
+		return err
+	}
+	return c.saramaClusterAdmin.AlterPartitionReassignments(topicName, fullAssignment)
+}
+
+// CancelPartitionReassignments cancels the reassignments in progress of the partitions of the topic, or of all its
+// partitions when partitions is nil. The partitions without reassignment in progress are ignored. It requires a kafka
+// client as the REST API can only list the reassignments.
+func (c *Client) CancelPartitionReassignments(clusterId, topicName string, partitions []int32) error {
+	if topicName == "" {
+		return errors.New("topic name is required to cancel the reassignments")
+	}
+	if c.saramaClient == nil || c.saramaClusterAdmin == nil {
+		return errors.New("CancelPartitionReassignments requires a kafka client")
+	}
+	reassignments, err := c.ListPartitionReassignments(clusterId, topicName)
+	if err != nil {
+		return err
+	}
+	reassignments = filterReassignments(reassignments, partitions)
+	if len(reassignments) == 0 {
+		return nil
+	}
+
+	if err := c.requireAPI(apiKeyAlterPartitionReassignments, 0, "AlterPartitionReassignments"); err != nil {
+		return err
+	}
+	// A nil target cancels the reassignment of the partition
+	assignment := make(map[int32][]int32, len(reassignments))
+	for _, r := range reassignments {
+		assignment[r.PartitionId] = nil
+	}
+	fullAssignment, err := c.completeAssignment(topicName, assignment)
+	if err != nil {
+		return err
+	}
+	return c.saramaClusterAdmin.AlterPartitionReassignments(topicName, fullAssignment)
+}
+
+// WaitForPartitionReassignments blocks until the reassignments of the partitions of the topic are completed. All the
+// partitions of the topic are awaited when partitions is nil, and all the topics of the cluster when topicName is empty.
+func (c *Client) WaitForPartitionReassignments(clusterId, topicName string, partitions []int32, options ReassignmentWaitOptions) error {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = defaultReassignmentWaitInterval
+	}
+	var deadline time.Time
+	if options.Timeout > 0 {
+		deadline = time.Now().Add(options.Timeout)
+	}
+
+	seen := make(map[string]bool)
+	for {
+		reassignments, err := c.ListPartitionReassignments(clusterId, topicName)
+		if err != nil {
+			return err
+		}
+		pending := filterReassignments(reassignments, partitions)
+		for _, r := range pending {
+			seen[fmt.Sprintf("%s-%d", r.TopicName, r.PartitionId)] = true
+		}
+		if options.Progress != nil {
+			options.Progress(ReassignmentProgress{
+				Total:     len(seen),
+				Completed: len(seen) - len(pending),
+				Pending:   pending,
+			})
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("timeout while waiting for the reassignment of %d partitions", len(pending))
+		}
+		time.Sleep(interval)
+	}
+}
+
+// completeAssignment builds the assignment of the partitions 0 to the highest partition of the assignment, as sarama
+// reassigns all of them. The missing partitions keep the target replicas of their reassignment in progress, or their
+// current replicas.
+func (c *Client) completeAssignment(topicName string, assignment map[int32][]int32) ([][]int32, error) {
+	if err := c.saramaClient.RefreshMetadata(); err != nil {
+		return nil, err
+	}
+	partitions, err := c.saramaClient.Partitions(topicName)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[int32]bool, len(partitions))
+	for _, p := range partitions {
+		existing[p] = true
+	}
+
+	maxPartition := int32(-1)
+	for p := range assignment {
+		if !existing[p] {
+			return nil, fmt.Errorf("partition %d of topic %s does not exist", p, topicName)
+		}
+		if p > maxPartition {
+			maxPartition = p
+		}
+	}
+
+	var inProgress map[int32]*sarama.PartitionReplicaReassignmentsStatus
+	fullAssignment := make([][]int32, maxPartition+1)
+	for p := int32(0); p <= maxPartition; p++ {
+		if replicas, ok := assignment[p]; ok {
+			fullAssignment[p] = replicas
+			continue
+		}
+
+		if inProgress == nil {
+			statusMap, err := c.saramaClusterAdmin.ListPartitionReassignments(topicName, partitions)
+			if err != nil {
+				return nil, err
+			}
+			inProgress = statusMap[topicName]
+			if inProgress == nil {
+				inProgress = make(map[int32]*sarama.PartitionReplicaReassignmentsStatus)
+			}
+		}
+		if status, ok := inProgress[p]; ok {
+			fullAssignment[p] = targetReplicas(status)
+			continue
+		}
+
+		replicas, err := c.saramaClient.Replicas(topicName, p)
+		if err != nil {
+			return nil, err
+		}
+		if len(replicas) == 0 {
+			return nil, fmt.Errorf("unable to find the replicas of the partition %d of topic %s", p, topicName)
+		}
+		fullAssignment[p] = replicas
+	}
+	return fullAssignment, nil
+}
+
+// targetReplicas returns the replicas of the partition once its reassignment is completed
+func targetReplicas(status *sarama.PartitionReplicaReassignmentsStatus) []int32 {
+	removing := make(map[int32]bool, len(status.RemovingReplicas))
+	for _, r := range status.RemovingReplicas {
+		removing[r] = true
+	}
+	replicas := make([]int32, 0, len(status.Replicas))
+	for _, r := range status.Replicas {
+		if !removing[r] {
+			replicas = append(replicas, r)
+		}
+	}
+	return replicas
+}
+
+func validateReassignment(assignment map[int32][]int32) error {
+	if len(assignment) == 0 {
+		return errors.New("empty reassignment")
+	}
+	for p, replicas := range assignment {
+		if p < 0 {
+			return fmt.Errorf("invalid partition %d", p)
+		}
+		if len(replicas) == 0 {
+			return fmt.Errorf("no replicas for the partition %d", p)
+		}
+		used := make(map[int32]bool, len(replicas))
+		for _, r := range replicas {
+			if used[r] {
+				return fmt.Errorf("duplicate replica %d for the partition %d", r, p)
+			}
+			used[r] = true
+		}
+	}
+	return nil
+}
+
+// filterReassignments keeps the reassignments of the partitions, all of them when partitions is nil
+func filterReassignments(reassignments []PartitionReassignment, partitions []int32) []PartitionReassignment {
+	if partitions == nil {
+		return reassignments
+	}
+	wanted := make(map[int32]bool, len(partitions))
+	for _, p := range partitions {
+		wanted[p] = true
+	}
+	filtered := make([]PartitionReassignment, 0, len(reassignments))
+	for _, r := range reassignments {
+		if wanted[r.PartitionId] {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+func sortReassignments(reassignments []PartitionReassignment) {
+	sort.Slice(reassignments, func(i, j int) bool {
+		if reassignments[i].TopicName != reassignments[j].TopicName {
+			return reassignments[i].TopicName < reassignments[j].TopicName
+		}
+		return reassignments[i].PartitionId < reassignments[j].PartitionId
+	})
+}
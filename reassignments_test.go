@@ -0,0 +1,194 @@
+package confluent
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReassignments_ListWithKafkaClient(t *testing.T) {
+	mk := MockKafkaClient{TopicsExpected: []string{"topic-2", "topic-1"}}
+	admin := &MockKafkaAdmin{}
+	admin.ListPartitionReassignmentsFn = func(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+		assert.Equal(t, []int32{1, 2}, partitions)
+		if topic == "topic-1" {
+			return map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus{
+				topic: {
+					2: {Replicas: []int32{1, 2, 3}, AddingReplicas: []int32{3}, RemovingReplicas: []int32{1}},
+					1: {Replicas: []int32{2, 3}, AddingReplicas: []int32{3}},
+				},
+			}, nil
+		}
+		return map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus{}, nil
+	}
+	c := NewClient(&MockHttpClient{}, &mk, admin)
+
+	reassignments, err := c.ListPartitionReassignments(clusterId, "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []PartitionReassignment{
+			{ClusterID: clusterId, TopicName: "topic-1", PartitionId: 1, Replicas: []int32{2, 3}, AddingReplicas: []int32{3}},
+			{ClusterID: clusterId, TopicName: "topic-1", PartitionId: 2, Replicas: []int32{1, 2, 3}, AddingReplicas: []int32{3}, RemovingReplicas: []int32{1}},
+		}, reassignments)
+	}
+
+	mk.SupportedAPIs = map[int]int{}
+	_, err = c.ListPartitionReassignments(clusterId, "topic-1")
+	assert.Equal(t, errors.New("ListPartitionReassignments v0 is not supported by all brokers of the cluster"), err)
+
+	mk.SupportedAPIs, mk.MockVersion = nil, sarama.V2_3_0_0
+	_, err = c.ListPartitionReassignments(clusterId, "topic-1")
+	assert.EqualError(t, err, "ListPartitionReassignments v0 requires kafka 2.4.0 but the client is configured for kafka 2.3.0, set KafkaVersion >= 2.4.0 or auto")
+}
+
+func TestReassignments_ListWithoutKafkaClient(t *testing.T) {
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodGet, method)
+		if uri == "/kafka/v3/clusters/cluster-1/topics/topic-1/partitions/-/reassignment" {
+			return []byte(`{"data": []}`), 200, "200 OK", nil
+		}
+		assert.Equal(t, "/kafka/v3/clusters/cluster-1/topics/-/partitions/-/reassignment", uri)
+		return []byte(`
+		{
+			"kind": "KafkaReassignmentList",
+			"data": [
+				{"kind": "KafkaReassignment", "cluster_id": "cluster-1", "topic_name": "topic-2", "partition_id": 0, "adding_replicas": [4], "removing_replicas": []},
+				{"kind": "KafkaReassignment", "cluster_id": "cluster-1", "topic_name": "topic-1", "partition_id": 3, "adding_replicas": [], "removing_replicas": [2]}
+			]
+		}`), 200, "200 OK", nil
+	}
+	c := NewClient(&mock, nil, nil)
+
+	reassignments, err := c.ListPartitionReassignments(clusterId, "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, len(reassignments))
+		assert.Equal(t, "topic-1", reassignments[0].TopicName)
+		assert.Equal(t, []int32{2}, reassignments[0].RemovingReplicas)
+		assert.Equal(t, []int32{4}, reassignments[1].AddingReplicas)
+	}
+
+	reassignments, err = c.ListPartitionReassignments(clusterId, "topic-1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, len(reassignments))
+	}
+
+	_, err = c.ListPartitionReassignments("", "topic-1")
+	assert.Equal(t, errors.New("ClusterID is required to list the reassignments without kafka client"), err)
+}
+
+func TestReassignments_AlterWithKafkaClient(t *testing.T) {
+	mk := MockKafkaClient{}
+	mk.ReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		return []int32{partitionId + 10}, nil
+	}
+	admin := &MockKafkaAdmin{}
+	admin.ListPartitionReassignmentsFn = func(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+		return map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus{
+			topic: {1: {Replicas: []int32{1, 2, 3}, AddingReplicas: []int32{3}, RemovingReplicas: []int32{1}}},
+		}, nil
+	}
+	var altered [][]int32
+	admin.AlterPartitionReassignmentsFn = func(topic string, assignment [][]int32) error {
+		assert.Equal(t, "topic-1", topic)
+		altered = assignment
+		return nil
+	}
+	c := NewClient(&MockHttpClient{}, &mk, admin)
+
+	// The partition 0 keeps its replicas, the partition 1 the target of its reassignment
+	err := c.AlterPartitionReassignments(clusterId, "topic-1", map[int32][]int32{2: {4, 5}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]int32{{10}, {2, 3}, {4, 5}}, altered)
+	}
+
+	err = c.AlterPartitionReassignments(clusterId, "topic-1", map[int32][]int32{3: {4, 5}})
+	assert.Equal(t, errors.New("partition 3 of topic topic-1 does not exist"), err)
+
+	err = c.AlterPartitionReassignments(clusterId, "topic-1", map[int32][]int32{1: {4, 4}})
+	assert.Equal(t, errors.New("duplicate replica 4 for the partition 1"), err)
+
+	err = c.AlterPartitionReassignments(clusterId, "topic-1", map[int32][]int32{1: {}})
+	assert.Equal(t, errors.New("no replicas for the partition 1"), err)
+}
+
+func TestReassignments_AlterAndCancelWithoutKafkaClient(t *testing.T) {
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		t.Errorf("Unexpected request %s %s", method, uri)
+		return nil, 404, "404 Not Found", nil
+	}
+	c := NewClient(&mock, nil, nil)
+
+	err := c.AlterPartitionReassignments(clusterId, "topic-1", map[int32][]int32{2: {3, 1}, 0: {1, 3}})
+	assert.Equal(t, errors.New("AlterPartitionReassignments requires a kafka client"), err)
+
+	err = c.CancelPartitionReassignments(clusterId, "topic-1", []int32{1, 2})
+	assert.Equal(t, errors.New("CancelPartitionReassignments requires a kafka client"), err)
+}
+
+func TestReassignments_CancelWithKafkaClient(t *testing.T) {
+	mk := MockKafkaClient{}
+	mk.ReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		return []int32{partitionId + 10}, nil
+	}
+	admin := &MockKafkaAdmin{}
+	admin.ListPartitionReassignmentsFn = func(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+		return map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus{
+			topic: {2: {Replicas: []int32{1, 2}, AddingReplicas: []int32{2}}},
+		}, nil
+	}
+	altered := false
+	admin.AlterPartitionReassignmentsFn = func(topic string, assignment [][]int32) error {
+		altered = true
+		assert.Equal(t, [][]int32{{10}, {11}, nil}, assignment)
+		return nil
+	}
+	c := NewClient(&MockHttpClient{}, &mk, admin)
+
+	assert.NoError(t, c.CancelPartitionReassignments(clusterId, "topic-1", nil))
+	assert.True(t, altered)
+
+	altered = false
+	assert.NoError(t, c.CancelPartitionReassignments(clusterId, "topic-1", []int32{1}))
+	assert.False(t, altered)
+}
+
+func TestReassignments_Wait(t *testing.T) {
+	responses := []string{
+		`{"data": [{"topic_name": "topic-1", "partition_id": 0}, {"topic_name": "topic-1", "partition_id": 1}]}`,
+		`{"data": [{"topic_name": "topic-1", "partition_id": 1}]}`,
+		`{"data": []}`,
+	}
+	calls := 0
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		r := responses[calls]
+		calls++
+		return []byte(r), 200, "200 OK", nil
+	}
+	c := NewClient(&mock, nil, nil)
+
+	progress := make([]ReassignmentProgress, 0)
+	err := c.WaitForPartitionReassignments(clusterId, "topic-1", nil, ReassignmentWaitOptions{
+		Interval: 1,
+		Progress: func(p ReassignmentProgress) {
+			progress = append(progress, p)
+		},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, len(progress))
+		assert.Equal(t, 2, progress[0].Total)
+		assert.Equal(t, 0, progress[0].Completed)
+		assert.Equal(t, 1, progress[1].Completed)
+		assert.Equal(t, 2, progress[2].Completed)
+		assert.Equal(t, 0, len(progress[2].Pending))
+	}
+
+	calls = 0
+	err = c.WaitForPartitionReassignments(clusterId, "topic-1", []int32{1}, ReassignmentWaitOptions{Interval: 1, Timeout: 1})
+	assert.Equal(t, errors.New("timeout while waiting for the reassignment of 1 partitions"), err)
+}
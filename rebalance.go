@@ -0,0 +1,539 @@
+package confluent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// BrokerBalance is the number of replicas and preferred leaders hosted by a broker
+type BrokerBalance struct {
+	BrokerID int32
+	Rack     string
+	Replicas int
+	Leaders  int
+}
+
+// ClusterBalance is the distribution of the replicas and the preferred leaders on the brokers of the cluster
+type ClusterBalance struct {
+	// Brokers are sorted by broker id
+	Brokers []BrokerBalance
+	// ReplicasImbalance and LeadersImbalance are the differences between the most and the least loaded brokers
+	ReplicasImbalance int
+	LeadersImbalance  int
+	// RackSkewedPartitions is the number of partitions whose replicas could be spread over more racks
+	RackSkewedPartitions int
+}
+
+// PartitionMove is the reassignment of a partition, only the order of the replicas changes when only its preferred
+// leader moves
+type PartitionMove struct {
+	Topic     string
+	Partition int32
+	Before    []int32
+	After     []int32
+}
+
+type RebalanceOptions struct {
+	// Decommission lists the brokers to move all the replicas off
+	Decommission []int32
+	// MaxMoves limits the number of replicas moved to balance the brokers, there is no limit when 0. The replicas
+	// of the decommissioned brokers and of the partitions skewed over racks are always moved.
+	MaxMoves int
+}
+
+// RebalancePlan is the reassignment of the partitions of the cluster, with the balance of the cluster before and
+// after it
+type RebalancePlan struct {
+	// Moves are sorted by topic and partition
+	Moves  []PartitionMove
+	Before ClusterBalance
+	After  ClusterBalance
+}
+
+// partitionReplicas is the replicas of a partition while planning a rebalance
+type partitionReplicas struct {
+	topic     string
+	partition int32
+	before    []int32
+	replicas  []int32
+}
+
+// rebalancer moves the replicas of the partitions between the eligible brokers of the placer, whose loads are kept up
+// to date. The replicas of the offline brokers stay in place unless the brokers are decommissioned.
+type rebalancer struct {
+	placer         *replicaPlacer
+	racks          map[int32]string
+	decommissioned map[int32]bool
+	partitions     []*partitionReplicas
+}
+
+// DescribeClusterBalance returns the distribution of the replicas and the preferred leaders of the cluster
+func (c *Client) DescribeClusterBalance() (*ClusterBalance, error) {
+	loads, partitions, err := c.clusterReplicas()
+	if err != nil {
+		return nil, err
+	}
+	balance := clusterBalance(loads, partitions, false, nil)
+	return &balance, nil
+}
+
+// PlanRebalance plans the reassignment of the partitions of the cluster that balances the replicas and the preferred
+// leaders over the brokers with as few replica moves as possible. The partitions are first moved off the
+// decommissioned brokers and spread over the racks, then the replicas are moved from the most to the least loaded
+// brokers, and the preferred leaders are swapped with other replicas. The plan can be reviewed, exported with
+// ReassignmentJSON and executed with ExecuteRebalancePlan. The offline brokers, which host replicas but are missing
+// from the metadata, receive no replica and keep theirs unless they are decommissioned.
+func (c *Client) PlanRebalance(options RebalanceOptions) (*RebalancePlan, error) {
+	loads, partitions, err := c.clusterReplicas()
+	if err != nil {
+		return nil, err
+	}
+
+	decommissioned := make(map[int32]bool, len(options.Decommission))
+	for _, b := range options.Decommission {
+		decommissioned[b] = true
+	}
+	known := make(map[int32]bool, len(loads))
+	eligible := make([]brokerLoad, 0, len(loads))
+	racks := make(map[int32]string, len(loads))
+	for _, l := range loads {
+		known[l.id] = true
+		racks[l.id] = l.rack
+		if !decommissioned[l.id] && !l.offline {
+			eligible = append(eligible, l)
+		}
+	}
+	for _, b := range options.Decommission {
+		if !known[b] {
+			return nil, fmt.Errorf("unknown broker %d", b)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil, errors.New("no broker left to host the replicas")
+	}
+
+	r := &rebalancer{
+		placer:         newReplicaPlacer(eligible, c.placementSeed),
+		racks:          racks,
+		decommissioned: decommissioned,
+		partitions:     partitions,
+	}
+	if err := r.decommission(); err != nil {
+		return nil, err
+	}
+	r.spreadRacks()
+	r.balanceReplicas(options.MaxMoves)
+	r.balanceLeaders()
+
+	plan := &RebalancePlan{
+		Moves:  make([]PartitionMove, 0),
+		Before: clusterBalance(loads, partitions, false, nil),
+		After:  clusterBalance(loads, partitions, true, options.Decommission),
+	}
+	for _, p := range partitions {
+		if !equalReplicas(p.before, p.replicas) {
+			plan.Moves = append(plan.Moves, PartitionMove{Topic: p.topic, Partition: p.partition, Before: p.before, After: p.replicas})
+		}
+	}
+	return plan, nil
+}
+
+// ExecuteRebalancePlan submits the reassignments of the plan, WaitForPartitionReassignments waits for their end
+func (c *Client) ExecuteRebalancePlan(clusterId string, plan *RebalancePlan) error {
+	topics := make([]string, 0)
+	assignments := make(map[string]map[int32][]int32)
+	for _, m := range plan.Moves {
+		if _, ok := assignments[m.Topic]; !ok {
+			topics = append(topics, m.Topic)
+			assignments[m.Topic] = make(map[int32][]int32)
+		}
+		assignments[m.Topic][m.Partition] = m.After
+	}
+
+	for _, topic := range topics {
+		if err := c.AlterPartitionReassignments(clusterId, topic, assignments[topic]); err != nil {
+			return fmt.Errorf("topic %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// ReassignmentJSON returns the moves of the plan in the format of the reassignment file of kafka-reassign-partitions
+func (plan *RebalancePlan) ReassignmentJSON() ([]byte, error) {
+	type partition struct {
+		Topic     string   `json:"topic"`
+		Partition int32    `json:"partition"`
+		Replicas  []int32  `json:"replicas"`
+		LogDirs   []string `json:"log_dirs"`
+	}
+	body := struct {
+		Version    int         `json:"version"`
+		Partitions []partition `json:"partitions"`
+	}{Version: 1, Partitions: make([]partition, 0, len(plan.Moves))}
+
+	for _, m := range plan.Moves {
+		logDirs := make([]string, len(m.After))
+		for i := range logDirs {
+			logDirs[i] = "any"
+		}
+		body.Partitions = append(body.Partitions, partition{Topic: m.Topic, Partition: m.Partition, Replicas: m.After, LogDirs: logDirs})
+	}
+	return json.Marshal(body)
+}
+
+// clusterReplicas returns the brokers and the replicas of all the partitions of the cluster, sorted by topic and
+// partition. The brokers hosting replicas which are missing from the brokers of the metadata are offline.
+func (c *Client) clusterReplicas() ([]brokerLoad, []*partitionReplicas, error) {
+	if c.saramaClient == nil {
+		return nil, nil, errors.New("reading the replicas of the cluster requires a kafka client")
+	}
+	if err := c.saramaClient.RefreshMetadata(); err != nil {
+		return nil, nil, err
+	}
+
+	loads := make(map[int32]*brokerLoad)
+	for _, b := range c.saramaClient.Brokers() {
+		id := c.saramaClient.ID(b)
+		if id != -1 {
+			loads[id] = &brokerLoad{id: id, rack: c.saramaClient.Rack(b)}
+		}
+	}
+
+	topics, err := c.saramaClient.Topics()
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(topics)
+
+	partitions := make([]*partitionReplicas, 0)
+	for _, topic := range topics {
+		ids, err := c.saramaClient.Partitions(topic)
+		if err != nil {
+			return nil, nil, err
+		}
+		sorted := make([]int32, len(ids))
+		copy(sorted, ids)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		for _, p := range sorted {
+			replicas, err := c.saramaClient.Replicas(topic, p)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, replica := range replicas {
+				if _, ok := loads[replica]; !ok {
+					loads[replica] = &brokerLoad{id: replica, offline: true}
+				}
+			}
+			countReplicas(loads, replicas)
+			current := make([]int32, len(replicas))
+			copy(current, replicas)
+			partitions = append(partitions, &partitionReplicas{topic: topic, partition: p, before: replicas, replicas: current})
+		}
+	}
+	return sortedLoads(loads), partitions, nil
+}
+
+// decommission replaces the replicas hosted by the decommissioned brokers, at the same position
+func (r *rebalancer) decommission() error {
+	for _, p := range r.partitions {
+		for i, replica := range p.replicas {
+			if !r.decommissioned[replica] {
+				continue
+			}
+			if err := r.replace(p, i); err != nil {
+				return fmt.Errorf("unable to move the partition %d of topic %s off the broker %d: %w", p.partition, p.topic, replica, err)
+			}
+		}
+	}
+	return nil
+}
+
+// spreadRacks moves the replicas sharing a rack with another replica of their partition to the racks without replica
+func (r *rebalancer) spreadRacks() {
+	rackCount := r.eligibleRacks()
+	for _, p := range r.partitions {
+		for r.rackCount(p.replicas) < minInt(len(p.replicas), rackCount) {
+			i := r.duplicatedRackReplica(p.replicas)
+			dst, err := r.candidate(p, i)
+			if err != nil || !r.addsRack(p.replicas, i, dst.id) {
+				break
+			}
+			r.move(p, i, dst)
+		}
+	}
+}
+
+// balanceReplicas moves the replicas from the most to the least loaded brokers until they differ by at most one
+// replica, without reducing the number of racks of the partitions. The preferred leaders are moved last.
+func (r *rebalancer) balanceReplicas(maxMoves int) {
+	for moves := 0; maxMoves == 0 || moves < maxMoves; moves++ {
+		if !r.moveReplica() {
+			return
+		}
+	}
+}
+
+func (r *rebalancer) moveReplica() bool {
+	brokers := r.brokersBy(func(b *brokerLoad) int { return b.replicas })
+	for i := len(brokers) - 1; i > 0; i-- {
+		src := brokers[i]
+		for _, dst := range brokers[:i] {
+			if src.replicas-dst.replicas <= 1 {
+				break
+			}
+			for _, leader := range []bool{false, true} {
+				for _, p := range r.partitions {
+					j := indexOfReplica(p.replicas, src.id)
+					if j == -1 || (j == 0) != leader || indexOfReplica(p.replicas, dst.id) != -1 {
+						continue
+					}
+					if !r.keepsRacks(p.replicas, j, dst.id) {
+						continue
+					}
+					r.move(p, j, dst)
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// balanceLeaders swaps the preferred leaders with other replicas of their partition until the brokers differ by at
+// most one preferred leader, no replica is moved
+func (r *rebalancer) balanceLeaders() {
+	for n := 0; n < len(r.partitions); n++ {
+		if !r.swapLeader() {
+			return
+		}
+	}
+}
+
+func (r *rebalancer) swapLeader() bool {
+	brokers := r.brokersBy(func(b *brokerLoad) int { return b.leaders })
+	for i := len(brokers) - 1; i > 0; i-- {
+		src := brokers[i]
+		for _, dst := range brokers[:i] {
+			if src.leaders-dst.leaders <= 1 {
+				break
+			}
+			for _, p := range r.partitions {
+				if len(p.replicas) == 0 || p.replicas[0] != src.id {
+					continue
+				}
+				j := indexOfReplica(p.replicas, dst.id)
+				if j == -1 {
+					continue
+				}
+				p.replicas[0], p.replicas[j] = p.replicas[j], p.replicas[0]
+				src.leaders--
+				dst.leaders++
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replace moves the i-th replica of the partition to the broker chosen by the placer
+func (r *rebalancer) replace(p *partitionReplicas, i int) error {
+	dst, err := r.candidate(p, i)
+	if err != nil {
+		return err
+	}
+	r.move(p, i, dst)
+	return nil
+}
+
+// candidate returns the broker chosen by the placer to replace the i-th replica of the partition
+func (r *rebalancer) candidate(p *partitionReplicas, i int) (*brokerLoad, error) {
+	others := make([]int32, 0, len(p.replicas)-1)
+	others = append(others, p.replicas[:i]...)
+	others = append(others, p.replicas[i+1:]...)
+
+	placed, err := r.placer.place(others, 1)
+	if err != nil {
+		return nil, err
+	}
+	dst := r.placer.byId[placed[len(placed)-1]]
+	// The load is updated when the replica is moved
+	dst.replicas--
+	return dst, nil
+}
+
+// move moves the i-th replica of the partition to the broker and updates the loads
+func (r *rebalancer) move(p *partitionReplicas, i int, dst *brokerLoad) {
+	if src, ok := r.placer.byId[p.replicas[i]]; ok {
+		src.replicas--
+		if i == 0 {
+			src.leaders--
+		}
+	}
+	dst.replicas++
+	if i == 0 {
+		dst.leaders++
+	}
+
+	replicas := make([]int32, len(p.replicas))
+	copy(replicas, p.replicas)
+	replicas[i] = dst.id
+	p.replicas = replicas
+}
+
+// keepsRacks tells whether replacing the i-th replica by the broker keeps the number of racks of the partition
+func (r *rebalancer) keepsRacks(replicas []int32, i int, broker int32) bool {
+	moved := make([]int32, len(replicas))
+	copy(moved, replicas)
+	moved[i] = broker
+	return r.rackCount(moved) >= r.rackCount(replicas)
+}
+
+// addsRack tells whether replacing the i-th replica by the broker increases the number of racks of the partition
+func (r *rebalancer) addsRack(replicas []int32, i int, broker int32) bool {
+	moved := make([]int32, len(replicas))
+	copy(moved, replicas)
+	moved[i] = broker
+	return r.rackCount(moved) > r.rackCount(replicas)
+}
+
+// rackCount returns the number of racks of the replicas, the replicas without rack count as one rack each
+func (r *rebalancer) rackCount(replicas []int32) int {
+	racks := make(map[string]bool, len(replicas))
+	count := 0
+	for _, replica := range replicas {
+		rack := r.racks[replica]
+		if rack == "" {
+			count++
+		} else if !racks[rack] {
+			racks[rack] = true
+			count++
+		}
+	}
+	return count
+}
+
+// duplicatedRackReplica returns the last replica in a rack with another replica, or -1
+func (r *rebalancer) duplicatedRackReplica(replicas []int32) int {
+	seen := make(map[string]bool, len(replicas))
+	last := -1
+	for i, replica := range replicas {
+		rack := r.racks[replica]
+		if rack == "" {
+			continue
+		}
+		if seen[rack] {
+			last = i
+		}
+		seen[rack] = true
+	}
+	return last
+}
+
+// eligibleRacks returns the number of racks of the eligible brokers, or the number of brokers when they have no rack
+func (r *rebalancer) eligibleRacks() int {
+	ids := make([]int32, 0, len(r.placer.brokers))
+	for _, b := range r.placer.brokers {
+		ids = append(ids, b.id)
+	}
+	return r.rackCount(ids)
+}
+
+// brokersBy returns the eligible brokers sorted by increasing value, then by id
+func (r *rebalancer) brokersBy(value func(b *brokerLoad) int) []*brokerLoad {
+	brokers := make([]*brokerLoad, len(r.placer.brokers))
+	copy(brokers, r.placer.brokers)
+	sort.SliceStable(brokers, func(i, j int) bool {
+		if value(brokers[i]) != value(brokers[j]) {
+			return value(brokers[i]) < value(brokers[j])
+		}
+		return brokers[i].id < brokers[j].id
+	})
+	return brokers
+}
+
+// clusterBalance computes the balance of the replicas of the partitions before or after the rebalance, the excluded
+// brokers are ignored unless they host replicas
+func clusterBalance(loads []brokerLoad, partitions []*partitionReplicas, after bool, excluded []int32) ClusterBalance {
+	balances := make(map[int32]*BrokerBalance, len(loads))
+	racks := make(map[int32]string, len(loads))
+	for _, l := range loads {
+		balances[l.id] = &BrokerBalance{BrokerID: l.id, Rack: l.rack}
+		racks[l.id] = l.rack
+	}
+	for _, b := range excluded {
+		delete(balances, b)
+	}
+
+	r := &rebalancer{racks: racks}
+	rackIds := make([]int32, 0, len(balances))
+	for id := range balances {
+		rackIds = append(rackIds, id)
+	}
+	rackCount := r.rackCount(rackIds)
+
+	skewed := 0
+	for _, p := range partitions {
+		replicas := p.before
+		if after {
+			replicas = p.replicas
+		}
+		for i, replica := range replicas {
+			b, ok := balances[replica]
+			if !ok {
+				b = &BrokerBalance{BrokerID: replica, Rack: racks[replica]}
+				balances[replica] = b
+			}
+			b.Replicas++
+			if i == 0 {
+				b.Leaders++
+			}
+		}
+		if r.rackCount(replicas) < minInt(len(replicas), rackCount) {
+			skewed++
+		}
+	}
+
+	balance := ClusterBalance{Brokers: make([]BrokerBalance, 0, len(balances)), RackSkewedPartitions: skewed}
+	for _, b := range balances {
+		balance.Brokers = append(balance.Brokers, *b)
+	}
+	sort.Slice(balance.Brokers, func(i, j int) bool { return balance.Brokers[i].BrokerID < balance.Brokers[j].BrokerID })
+
+	if len(balance.Brokers) != 0 {
+		minReplicas, maxReplicas := balance.Brokers[0].Replicas, balance.Brokers[0].Replicas
+		minLeaders, maxLeaders := balance.Brokers[0].Leaders, balance.Brokers[0].Leaders
+		for _, b := range balance.Brokers[1:] {
+			minReplicas, maxReplicas = minInt(minReplicas, b.Replicas), maxInt(maxReplicas, b.Replicas)
+			minLeaders, maxLeaders = minInt(minLeaders, b.Leaders), maxInt(maxLeaders, b.Leaders)
+		}
+		balance.ReplicasImbalance = maxReplicas - minReplicas
+		balance.LeadersImbalance = maxLeaders - minLeaders
+	}
+	return balance
+}
+
+func indexOfReplica(replicas []int32, replica int32) int {
+	for i, r := range replicas {
+		if r == replica {
+			return i
+		}
+	}
+	return -1
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,218 @@
+package confluent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRebalanceMockClient(racks map[int32]string, replicas map[string][][]int32) *MockKafkaClient {
+	mk := &MockKafkaClient{}
+	for id := range racks {
+		mk.BrokersExpected = append(mk.BrokersExpected, sarama.NewBroker("broker-"+string(rune('0'+id))+":9092"))
+	}
+	for topic := range replicas {
+		mk.TopicsExpected = append(mk.TopicsExpected, topic)
+	}
+	mk.IDFn = func(broker *sarama.Broker) int32 {
+		return int32(broker.Addr()[7] - '0')
+	}
+	mk.RackFn = func(broker *sarama.Broker) string {
+		return racks[int32(broker.Addr()[7]-'0')]
+	}
+	mk.ReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		return replicas[topic][partitionId-1], nil
+	}
+	return mk
+}
+
+func TestRebalance_DescribeClusterBalance(t *testing.T) {
+	mk := newRebalanceMockClient(
+		map[int32]string{1: "a", 2: "a", 3: "b"},
+		map[string][][]int32{"topic-1": {{1, 2}, {1, 3}}, "topic-2": {{2, 1}, {1, 2}}},
+	)
+	c := NewClient(&MockHttpClient{}, mk, &MockKafkaAdmin{})
+
+	balance, err := c.DescribeClusterBalance()
+	if assert.NoError(t, err) {
+		assert.Equal(t, &ClusterBalance{
+			Brokers: []BrokerBalance{
+				{BrokerID: 1, Rack: "a", Replicas: 4, Leaders: 3},
+				{BrokerID: 2, Rack: "a", Replicas: 3, Leaders: 1},
+				{BrokerID: 3, Rack: "b", Replicas: 1, Leaders: 0},
+			},
+			ReplicasImbalance:    3,
+			LeadersImbalance:     3,
+			RackSkewedPartitions: 3,
+		}, balance)
+	}
+
+	_, err = NewClient(&MockHttpClient{}, nil, nil).DescribeClusterBalance()
+	assert.Equal(t, errors.New("reading the replicas of the cluster requires a kafka client"), err)
+}
+
+func TestRebalance_PlanRebalance(t *testing.T) {
+	mk := newRebalanceMockClient(
+		map[int32]string{1: "", 2: "", 3: ""},
+		map[string][][]int32{"topic-1": {{1, 2}, {1, 2}}, "topic-2": {{1, 2}, {2, 1}}, "topic-3": {{1, 2}, {1, 2}}},
+	)
+	c := NewClient(&MockHttpClient{}, mk, &MockKafkaAdmin{})
+
+	plan, err := c.PlanRebalance(RebalanceOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 6, plan.Before.ReplicasImbalance)
+	assert.Equal(t, 0, plan.After.ReplicasImbalance)
+	assert.Equal(t, 0, plan.After.LeadersImbalance)
+	for _, b := range plan.After.Brokers {
+		assert.Equal(t, 4, b.Replicas)
+		assert.Equal(t, 2, b.Leaders)
+	}
+	// The fewest replicas are moved to balance the brokers
+	moved := 0
+	for _, m := range plan.Moves {
+		for _, r := range m.After {
+			if indexOfReplica(m.Before, r) == -1 {
+				moved++
+			}
+		}
+	}
+	assert.Equal(t, 4, moved)
+
+	plan, err = c.PlanRebalance(RebalanceOptions{MaxMoves: 1})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []BrokerBalance{
+			{BrokerID: 1, Replicas: 6, Leaders: 3},
+			{BrokerID: 2, Replicas: 5, Leaders: 2},
+			{BrokerID: 3, Replicas: 1, Leaders: 1},
+		}, plan.After.Brokers)
+	}
+
+	_, err = c.PlanRebalance(RebalanceOptions{Decommission: []int32{4}})
+	assert.Equal(t, errors.New("unknown broker 4"), err)
+
+	_, err = c.PlanRebalance(RebalanceOptions{Decommission: []int32{1, 2, 3}})
+	assert.Equal(t, errors.New("no broker left to host the replicas"), err)
+}
+
+func TestRebalance_PlanRebalanceDecommission(t *testing.T) {
+	mk := newRebalanceMockClient(
+		map[int32]string{1: "", 2: "", 3: "", 4: ""},
+		map[string][][]int32{"topic-1": {{1, 2}, {2, 3}}, "topic-2": {{3, 1}, {4, 1}}},
+	)
+	c := NewClient(&MockHttpClient{}, mk, &MockKafkaAdmin{})
+
+	plan, err := c.PlanRebalance(RebalanceOptions{Decommission: []int32{1}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, m := range plan.Moves {
+		assert.Equal(t, -1, indexOfReplica(m.After, 1))
+	}
+	if assert.Len(t, plan.After.Brokers, 3) {
+		assert.Equal(t, int32(2), plan.After.Brokers[0].BrokerID)
+	}
+	assert.LessOrEqual(t, plan.After.ReplicasImbalance, 1)
+	assert.LessOrEqual(t, plan.After.LeadersImbalance, 1)
+
+	_, err = c.PlanRebalance(RebalanceOptions{Decommission: []int32{1, 2, 3}})
+	assert.EqualError(t, err, "unable to move the partition 1 of topic topic-1 off the broker 2: not enough brokers")
+}
+
+func TestRebalance_PlanRebalanceOfflineBroker(t *testing.T) {
+	// The broker 4 hosts replicas but is missing from the brokers of the metadata
+	mk := newRebalanceMockClient(
+		map[int32]string{1: "", 2: "", 3: ""},
+		map[string][][]int32{"topic-1": {{4, 1}, {1, 4}, {1, 2}, {1, 2}}},
+	)
+	c := NewClient(&MockHttpClient{}, mk, &MockKafkaAdmin{})
+
+	plan, err := c.PlanRebalance(RebalanceOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, plan.Before.Brokers, BrokerBalance{BrokerID: 4, Replicas: 2, Leaders: 1})
+	assert.Contains(t, plan.After.Brokers, BrokerBalance{BrokerID: 4, Replicas: 2, Leaders: 1})
+	for _, m := range plan.Moves {
+		assert.Equal(t, indexOfReplica(m.Before, 4), indexOfReplica(m.After, 4))
+	}
+
+	plan, err = c.PlanRebalance(RebalanceOptions{Decommission: []int32{4}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, plan.After.Brokers, 3)
+	for _, m := range plan.Moves {
+		assert.Equal(t, -1, indexOfReplica(m.After, 4))
+	}
+
+	// New replicas are not placed on the offline broker
+	loads, err := c.brokerLoads()
+	if assert.NoError(t, err) {
+		assert.Len(t, loads, 3)
+	}
+}
+
+func TestRebalance_PlanRebalanceRacks(t *testing.T) {
+	mk := newRebalanceMockClient(
+		map[int32]string{1: "a", 2: "a", 3: "b", 4: "b"},
+		map[string][][]int32{"topic-1": {{1, 2}, {3, 4}}},
+	)
+	c := NewClient(&MockHttpClient{}, mk, &MockKafkaAdmin{})
+
+	plan, err := c.PlanRebalance(RebalanceOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, plan.Before.RackSkewedPartitions)
+	assert.Equal(t, 0, plan.After.RackSkewedPartitions)
+	assert.Equal(t, []PartitionMove{
+		{Topic: "topic-1", Partition: 1, Before: []int32{1, 2}, After: []int32{1, 4}},
+		{Topic: "topic-1", Partition: 2, Before: []int32{3, 4}, After: []int32{3, 2}},
+	}, plan.Moves)
+}
+
+func TestRebalance_ExecuteRebalancePlan(t *testing.T) {
+	plan := &RebalancePlan{Moves: []PartitionMove{
+		{Topic: "topic-1", Partition: 1, Before: []int32{1, 2}, After: []int32{1, 3}},
+		{Topic: "topic-2", Partition: 1, Before: []int32{1, 2}, After: []int32{2, 1}},
+	}}
+
+	b, err := plan.ReassignmentJSON()
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"version":1,"partitions":[`+
+			`{"topic":"topic-1","partition":1,"replicas":[1,3],"log_dirs":["any","any"]},`+
+			`{"topic":"topic-2","partition":1,"replicas":[2,1],"log_dirs":["any","any"]}]}`, string(b))
+	}
+
+	assignments := make(map[string][][]int32)
+	admin := &MockKafkaAdmin{}
+	admin.ListPartitionReassignmentsFn = func(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+		return nil, nil
+	}
+	admin.AlterPartitionReassignmentsFn = func(topic string, assignment [][]int32) error {
+		assignments[topic] = assignment
+		return nil
+	}
+	mk := MockKafkaClient{}
+	mk.ReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		return []int32{partitionId + 10}, nil
+	}
+	c := NewClient(&MockHttpClient{}, &mk, admin)
+
+	if assert.NoError(t, c.ExecuteRebalancePlan(clusterId, plan)) {
+		assert.Equal(t, map[string][][]int32{
+			"topic-1": {{10}, {1, 3}},
+			"topic-2": {{10}, {2, 1}},
+		}, assignments)
+	}
+
+	admin.AlterPartitionReassignmentsFn = func(topic string, assignment [][]int32) error {
+		return sarama.ErrReassignmentInProgress
+	}
+	err = c.ExecuteRebalancePlan(clusterId, plan)
+	assert.Equal(t, "topic topic-1: "+sarama.ErrReassignmentInProgress.Error(), err.Error())
+}
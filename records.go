@@ -0,0 +1,87 @@
+package confluent
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// RecordsDeletion is the deletion of the records of a partition before an offset
+type RecordsDeletion struct {
+	Partition int32
+	// Offset is the earliest offset of the partition after the deletion
+	Offset int64
+	// Records is the number of offsets deleted, the transaction markers are counted
+	Records int64
+}
+
+// DeleteRecords deletes the records of the partitions of the topic before the offsets, sarama.OffsetNewest deletes
+// all the records of a partition. With dryRun, nothing is deleted. It returns the deletions sorted by partition, the
+// partitions without records before their offset are not changed.
+// @ref https://kafka.apache.org/protocol#The_Messages_DeleteRecords
+func (c *Client) DeleteRecords(topic string, offsets map[int32]int64, dryRun bool) ([]RecordsDeletion, error) {
+	if err := c.requireAPI(apiKeyDeleteRecords, 0, "DeleteRecords"); err != nil {
+		return nil, err
+	}
+	topicOffsets, err := c.GetTopicOffsets(topic, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.deleteRecords(topicOffsets, offsets, dryRun)
+}
+
+// DeleteRecordsBefore deletes the records of all the partitions of the topic before the time. With dryRun, nothing is
+// deleted.
+func (c *Client) DeleteRecordsBefore(topic string, before time.Time, dryRun bool) ([]RecordsDeletion, error) {
+	if err := c.requireAPI(apiKeyDeleteRecords, 0, "DeleteRecords"); err != nil {
+		return nil, err
+	}
+	topicOffsets, err := c.GetTopicOffsets(topic, &before)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[int32]int64, len(topicOffsets.Partitions))
+	for _, p := range topicOffsets.Partitions {
+		offsets[p.Partition] = *p.AtTime
+	}
+	return c.deleteRecords(topicOffsets, offsets, dryRun)
+}
+
+func (c *Client) deleteRecords(topicOffsets *TopicOffsets, offsets map[int32]int64, dryRun bool) ([]RecordsDeletion, error) {
+	partitions := make(map[int32]PartitionOffsets, len(topicOffsets.Partitions))
+	for _, p := range topicOffsets.Partitions {
+		partitions[p.Partition] = p
+	}
+
+	deletions := make([]RecordsDeletion, 0, len(offsets))
+	partitionOffsets := make(map[int32]int64, len(offsets))
+	for partition, offset := range offsets {
+		p, ok := partitions[partition]
+		if !ok {
+			return nil, fmt.Errorf("partition %d of topic %s does not exist", partition, topicOffsets.Topic)
+		}
+		if offset == sarama.OffsetNewest {
+			offset = p.Latest
+		}
+		if offset < 0 || offset > p.Latest {
+			return nil, fmt.Errorf("invalid offset %d for the partition %d of topic %s, the latest offset is %d", offset, partition, topicOffsets.Topic, p.Latest)
+		}
+		if offset <= p.Earliest {
+			continue
+		}
+		deletions = append(deletions, RecordsDeletion{Partition: partition, Offset: offset, Records: offset - p.Earliest})
+		partitionOffsets[partition] = offset
+	}
+	sort.Slice(deletions, func(i, j int) bool { return deletions[i].Partition < deletions[j].Partition })
+
+	if dryRun || len(partitionOffsets) == 0 {
+		return deletions, nil
+	}
+	if err := c.saramaClusterAdmin.DeleteRecords(topicOffsets.Topic, partitionOffsets); err != nil {
+		return nil, err
+	}
+	return deletions, nil
+}
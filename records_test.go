@@ -0,0 +1,81 @@
+package confluent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecords_DeleteRecords(t *testing.T) {
+	mk := MockKafkaClient{}
+	mk.GetOffsetFn = func(topic string, partitionId int32, time int64) (int64, error) {
+		switch time {
+		case sarama.OffsetOldest:
+			return int64(partitionId) * 10, nil
+		case sarama.OffsetNewest:
+			return int64(partitionId) * 100, nil
+		case 5000:
+			if partitionId == 1 {
+				return 42, nil
+			}
+			return -1, nil
+		}
+		return 0, errors.New("unexpected time")
+	}
+	var deleted map[int32]int64
+	admin := &MockKafkaAdmin{}
+	admin.DeleteRecordsFn = func(topic string, partitionOffsets map[int32]int64) error {
+		assert.Equal(t, "topic-1", topic)
+		deleted = partitionOffsets
+		return nil
+	}
+	c := NewClient(&MockHttpClient{}, &mk, admin)
+
+	deletions, err := c.DeleteRecords("topic-1", map[int32]int64{1: 50, 2: 5}, true)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []RecordsDeletion{{Partition: 1, Offset: 50, Records: 40}}, deletions)
+		assert.Nil(t, deleted)
+	}
+
+	deletions, err = c.DeleteRecords("topic-1", map[int32]int64{1: 50, 2: sarama.OffsetNewest}, false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []RecordsDeletion{
+			{Partition: 1, Offset: 50, Records: 40},
+			{Partition: 2, Offset: 200, Records: 180},
+		}, deletions)
+		assert.Equal(t, map[int32]int64{1: 50, 2: 200}, deleted)
+	}
+
+	deleted = nil
+	deletions, err = c.DeleteRecordsBefore("topic-1", time.Unix(5, 0), false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []RecordsDeletion{
+			{Partition: 1, Offset: 42, Records: 32},
+			{Partition: 2, Offset: 200, Records: 180},
+		}, deletions)
+		assert.Equal(t, map[int32]int64{1: 42, 2: 200}, deleted)
+	}
+
+	_, err = c.DeleteRecords("topic-1", map[int32]int64{3: 50}, false)
+	assert.Equal(t, errors.New("partition 3 of topic topic-1 does not exist"), err)
+
+	_, err = c.DeleteRecords("topic-1", map[int32]int64{1: 101}, false)
+	assert.Equal(t, errors.New("invalid offset 101 for the partition 1 of topic topic-1, the latest offset is 100"), err)
+
+	admin.DeleteRecordsFn = func(topic string, partitionOffsets map[int32]int64) error {
+		return sarama.ErrDeleteRecords{}
+	}
+	_, err = c.DeleteRecords("topic-1", map[int32]int64{1: 50}, false)
+	assert.Error(t, err)
+
+	mk.SupportedAPIs = map[int]int{}
+	_, err = c.DeleteRecordsBefore("topic-1", time.Unix(5, 0), true)
+	assert.Equal(t, errors.New("DeleteRecords v0 is not supported by all brokers of the cluster"), err)
+
+	mk.SupportedAPIs, mk.MockVersion = nil, sarama.V0_10_2_0
+	_, err = c.DeleteRecords("topic-1", map[int32]int64{1: 50}, false)
+	assert.EqualError(t, err, "DeleteRecords v0 requires kafka 0.11.0.0 but the client is configured for kafka 0.10.2.0, set KafkaVersion >= 0.11.0.0 or auto")
+}
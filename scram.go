@@ -0,0 +1,216 @@
+package confluent
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	scramDefaultIterations = 4096
+	scramMinIterations     = 4096
+	scramMaxIterations     = 16384
+	scramDefaultSaltLength = 32
+
+	// RESOURCE_NOT_FOUND is returned for users without SCRAM credentials, sarama does not define it
+	errScramResourceNotFound sarama.KError = 91
+)
+
+// ScramCredential describes one SCRAM credential of a user, Kafka never returns the password nor the salt
+type ScramCredential struct {
+	// Mechanism is "scram-sha256" or "scram-sha512", as in Config.SASLMechanism
+	Mechanism  string
+	Iterations int32
+}
+
+type ScramUser struct {
+	Name        string
+	Credentials []ScramCredential
+}
+
+type ScramCredentialUpsert struct {
+	Name      string
+	Mechanism string
+	Password  string
+
+	// Iterations defaults to 4096, Kafka accepts 4096 to 16384
+	Iterations int32
+
+	// Salt defaults to 32 random bytes
+	Salt []byte
+}
+
+type ScramCredentialDelete struct {
+	Name      string
+	Mechanism string
+}
+
+// DescribeScramCredentials returns the SCRAM credentials of the users, or of all users when none is given.
+// Users without credentials are returned with an empty list of credentials.
+func (c *Client) DescribeScramCredentials(users []string) ([]ScramUser, error) {
+	if err := c.requireAPI(apiKeyDescribeUserScramCredentials, 0, "DescribeUserScramCredentials"); err != nil {
+		return nil, err
+	}
+
+	results, err := c.saramaClusterAdmin.DescribeUserScramCredentials(users)
+	if err != nil {
+		return nil, err
+	}
+
+	scramUsers := make([]ScramUser, 0, len(results))
+	errs := make([]error, 0)
+	for _, r := range results {
+		if r.ErrorCode != sarama.ErrNoError && r.ErrorCode != errScramResourceNotFound {
+			errs = append(errs, scramResultError(r.User, r.ErrorCode, r.ErrorMessage))
+			continue
+		}
+		u := ScramUser{
+			Name: r.User,
+		}
+		for _, info := range r.CredentialInfos {
+			u.Credentials = append(u.Credentials, ScramCredential{
+				Mechanism:  scramMechanismName(info.Mechanism),
+				Iterations: info.Iterations,
+			})
+		}
+		scramUsers = append(scramUsers, u)
+	}
+
+	if len(errs) != 0 {
+		return scramUsers, errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
+	}
+	return scramUsers, nil
+}
+
+// UpsertScramCredentials creates or updates SCRAM credentials. The password is salted and hashed
+// on the client side, only the salted password is sent to the brokers.
+func (c *Client) UpsertScramCredentials(upserts []ScramCredentialUpsert) error {
+	if err := c.requireAPI(apiKeyAlterUserScramCredentials, 0, "AlterUserScramCredentials"); err != nil {
+		return err
+	}
+
+	req := make([]sarama.AlterUserScramCredentialsUpsert, 0, len(upserts))
+	for _, u := range upserts {
+		mechanism, err := parseScramMechanism(u.Mechanism)
+		if err != nil {
+			return err
+		}
+
+		iterations := u.Iterations
+		if iterations == 0 {
+			iterations = scramDefaultIterations
+		}
+		if iterations < scramMinIterations || iterations > scramMaxIterations {
+			return fmt.Errorf("invalid iterations %d for user %s: must be between %d and %d", iterations, u.Name, scramMinIterations, scramMaxIterations)
+		}
+
+		salt := u.Salt
+		if len(salt) == 0 {
+			salt = make([]byte, scramDefaultSaltLength)
+			if _, err := rand.Read(salt); err != nil {
+				return err
+			}
+		}
+
+		req = append(req, sarama.AlterUserScramCredentialsUpsert{
+			Name:       u.Name,
+			Mechanism:  mechanism,
+			Iterations: iterations,
+			Salt:       salt,
+			Password:   []byte(u.Password),
+		})
+	}
+
+	results, err := c.saramaClusterAdmin.UpsertUserScramCredentials(req)
+	if err != nil {
+		return err
+	}
+	return alterScramResultsError(results)
+}
+
+// DeleteScramCredentials removes SCRAM credentials of users
+func (c *Client) DeleteScramCredentials(deletes []ScramCredentialDelete) error {
+	if err := c.requireAPI(apiKeyAlterUserScramCredentials, 0, "AlterUserScramCredentials"); err != nil {
+		return err
+	}
+
+	req := make([]sarama.AlterUserScramCredentialsDelete, 0, len(deletes))
+	for _, d := range deletes {
+		mechanism, err := parseScramMechanism(d.Mechanism)
+		if err != nil {
+			return err
+		}
+		req = append(req, sarama.AlterUserScramCredentialsDelete{
+			Name:      d.Name,
+			Mechanism: mechanism,
+		})
+	}
+
+	results, err := c.saramaClusterAdmin.DeleteUserScramCredentials(req)
+	if err != nil {
+		return err
+	}
+	return alterScramResultsError(results)
+}
+
+// requireAPI returns an error when one of the brokers does not support the version of the Kafka API, or when the
+// client is configured with a Kafka version too old to send it
+func (c *Client) requireAPI(apiKey int, version int, name string) error {
+	if c.saramaClient == nil || c.saramaClusterAdmin == nil {
+		return errors.New(name + " requires a kafka client")
+	}
+	if !c.SupportsAPI(apiKey, version) {
+		return fmt.Errorf("%s v%d is not supported by all brokers of the cluster", name, version)
+	}
+	if versions := apiKafkaVersions[apiKey]; version < len(versions) {
+		configured := c.saramaClient.Config().Version
+		if !configured.IsAtLeast(versions[version]) {
+			return fmt.Errorf("%s v%d requires kafka %s but the client is configured for kafka %s, set KafkaVersion >= %s or auto", name, version, versions[version], configured, versions[version])
+		}
+	}
+	return nil
+}
+
+func alterScramResultsError(results []*sarama.AlterUserScramCredentialsResult) error {
+	errs := make([]error, 0)
+	for _, r := range results {
+		if r.ErrorCode != sarama.ErrNoError {
+			errs = append(errs, scramResultError(r.User, r.ErrorCode, r.ErrorMessage))
+		}
+	}
+	if len(errs) != 0 {
+		return errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
+	}
+	return nil
+}
+
+func scramResultError(user string, code sarama.KError, message *string) error {
+	if message != nil && *message != "" {
+		return fmt.Errorf("user %s: %s", user, *message)
+	}
+	return fmt.Errorf("user %s: %s", user, code.Error())
+}
+
+// parseScramMechanism accepts the Config.SASLMechanism names as well as the SASL names, e.g. SCRAM-SHA-512
+func parseScramMechanism(mechanism string) (sarama.ScramMechanismType, error) {
+	switch strings.ReplaceAll(strings.ToLower(mechanism), "-", "") {
+	case "scramsha256":
+		return sarama.SCRAM_MECHANISM_SHA_256, nil
+	case "scramsha512":
+		return sarama.SCRAM_MECHANISM_SHA_512, nil
+	}
+	return sarama.SCRAM_MECHANISM_UNKNOWN, errors.New("invalid scram mechanism \"" + mechanism + "\": can only be \"scram-sha256\" or \"scram-sha512\"")
+}
+
+func scramMechanismName(mechanism sarama.ScramMechanismType) string {
+	switch mechanism {
+	case sarama.SCRAM_MECHANISM_SHA_256:
+		return "scram-sha256"
+	case sarama.SCRAM_MECHANISM_SHA_512:
+		return "scram-sha512"
+	}
+	return mechanism.String()
+}
@@ -0,0 +1,50 @@
+package confluent
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg/scram"
+)
+
+var (
+	scramSHA256 scram.HashGeneratorFcn = sha256.New
+	scramSHA512 scram.HashGeneratorFcn = sha512.New
+)
+
+// scramClient implements sarama.SCRAMClient to authenticate with SASL/SCRAM-SHA-256 and SASL/SCRAM-SHA-512
+type scramClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	scram.HashGeneratorFcn
+
+	// nonceGenerator replaces the random client nonce when set
+	nonceGenerator scram.NonceGeneratorFcn
+}
+
+func newSCRAMClientGenerator(hashGenerator scram.HashGeneratorFcn) func() sarama.SCRAMClient {
+	return func() sarama.SCRAMClient {
+		return &scramClient{HashGeneratorFcn: hashGenerator}
+	}
+}
+
+func (sc *scramClient) Begin(userName, password, authzID string) (err error) {
+	sc.Client, err = sc.HashGeneratorFcn.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	if sc.nonceGenerator != nil {
+		sc.Client = sc.Client.WithNonceGenerator(sc.nonceGenerator)
+	}
+	sc.ClientConversation = sc.Client.NewConversation()
+	return nil
+}
+
+func (sc *scramClient) Step(challenge string) (response string, err error) {
+	return sc.ClientConversation.Step(challenge)
+}
+
+func (sc *scramClient) Done() bool {
+	return sc.ClientConversation.Done()
+}
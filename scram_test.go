@@ -0,0 +1,107 @@
+package confluent
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScram_DescribeScramCredentialsSuccess(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	admin := &MockKafkaAdmin{}
+	admin.DescribeUserScramCredentialsFn = func(users []string) ([]*sarama.DescribeUserScramCredentialsResult, error) {
+		assert.Equal(t, []string{"alice", "bob"}, users)
+		return []*sarama.DescribeUserScramCredentialsResult{
+			{
+				User: "alice",
+				CredentialInfos: []*sarama.UserScramCredentialsResponseInfo{
+					{Mechanism: sarama.SCRAM_MECHANISM_SHA_512, Iterations: 8192},
+				},
+			},
+			{
+				User:      "bob",
+				ErrorCode: errScramResourceNotFound,
+			},
+		}, nil
+	}
+	c := NewClient(&mock, &mk, admin)
+	users, err := c.DescribeScramCredentials([]string{"alice", "bob"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []ScramUser{
+			{Name: "alice", Credentials: []ScramCredential{{Mechanism: "scram-sha512", Iterations: 8192}}},
+			{Name: "bob"},
+		}, users)
+	}
+}
+
+func TestScram_DescribeScramCredentialsNotSupported(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{SupportedAPIs: map[int]int{}}
+	c := NewClient(&mock, &mk, &MockKafkaAdmin{})
+	_, err := c.DescribeScramCredentials(nil)
+	assert.EqualError(t, err, "DescribeUserScramCredentials v0 is not supported by all brokers of the cluster")
+}
+
+func TestScram_DescribeScramCredentialsDefaultVersion(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{MockVersion: defaultKafkaVersion}
+	c := NewClient(&mock, &mk, &MockKafkaAdmin{})
+	_, err := c.DescribeScramCredentials(nil)
+	assert.EqualError(t, err, "DescribeUserScramCredentials v0 requires kafka 2.7.0 but the client is configured for kafka 2.4.0, set KafkaVersion >= 2.7.0 or auto")
+}
+
+func TestScram_UpsertScramCredentialsSuccess(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	admin := &MockKafkaAdmin{}
+	admin.AlterUserScramCredentialsFn = func(upsert []sarama.AlterUserScramCredentialsUpsert, delete []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error) {
+		assert.Nil(t, delete)
+		if assert.Equal(t, 2, len(upsert)) {
+			assert.Equal(t, sarama.SCRAM_MECHANISM_SHA_256, upsert[0].Mechanism)
+			assert.Equal(t, int32(4096), upsert[0].Iterations)
+			assert.Equal(t, 32, len(upsert[0].Salt))
+			assert.Equal(t, sarama.SCRAM_MECHANISM_SHA_512, upsert[1].Mechanism)
+			assert.Equal(t, int32(10000), upsert[1].Iterations)
+			assert.Equal(t, []byte("salt"), upsert[1].Salt)
+			assert.Equal(t, []byte("secret"), upsert[1].Password)
+		}
+		return []*sarama.AlterUserScramCredentialsResult{{User: "alice"}}, nil
+	}
+	c := NewClient(&mock, &mk, admin)
+	err := c.UpsertScramCredentials([]ScramCredentialUpsert{
+		{Name: "alice", Mechanism: "scram-sha256", Password: "secret"},
+		{Name: "alice", Mechanism: "SCRAM-SHA-512", Password: "secret", Iterations: 10000, Salt: []byte("salt")},
+	})
+	assert.NoError(t, err)
+}
+
+func TestScram_UpsertScramCredentialsInvalid(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	c := NewClient(&mock, &mk, &MockKafkaAdmin{})
+
+	err := c.UpsertScramCredentials([]ScramCredentialUpsert{{Name: "alice", Mechanism: "plain", Password: "secret"}})
+	assert.EqualError(t, err, "invalid scram mechanism \"plain\": can only be \"scram-sha256\" or \"scram-sha512\"")
+
+	err = c.UpsertScramCredentials([]ScramCredentialUpsert{{Name: "alice", Mechanism: "scram-sha256", Password: "secret", Iterations: 100}})
+	assert.EqualError(t, err, "invalid iterations 100 for user alice: must be between 4096 and 16384")
+}
+
+func TestScram_DeleteScramCredentialsFail(t *testing.T) {
+	mock := MockHttpClient{}
+	mk := MockKafkaClient{}
+	admin := &MockKafkaAdmin{}
+	admin.AlterUserScramCredentialsFn = func(upsert []sarama.AlterUserScramCredentialsUpsert, delete []sarama.AlterUserScramCredentialsDelete) ([]*sarama.AlterUserScramCredentialsResult, error) {
+		assert.Nil(t, upsert)
+		assert.Equal(t, []sarama.AlterUserScramCredentialsDelete{{Name: "bob", Mechanism: sarama.SCRAM_MECHANISM_SHA_256}}, delete)
+		message := "Attempt to delete a user credential that does not exist"
+		return []*sarama.AlterUserScramCredentialsResult{{User: "bob", ErrorCode: sarama.KError(90), ErrorMessage: &message}}, nil
+	}
+	c := NewClient(&mock, &mk, admin)
+	err := c.DeleteScramCredentials([]ScramCredentialDelete{{Name: "bob", Mechanism: "scram-sha256"}})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "user bob: Attempt to delete a user credential that does not exist")
+	}
+}
@@ -0,0 +1,158 @@
+package confluent
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	configLeaderThrottledRate       = "leader.replication.throttled.rate"
+	configFollowerThrottledRate     = "follower.replication.throttled.rate"
+	configLeaderThrottledReplicas   = "leader.replication.throttled.replicas"
+	configFollowerThrottledReplicas = "follower.replication.throttled.replicas"
+
+	configOperationDelete = "DELETE"
+)
+
+// ReplicationThrottle limits the bandwidth used to copy the new replicas during a reassignment, like the
+// --throttle option of kafka-reassign-partitions
+type ReplicationThrottle struct {
+	// Rate in bytes per second, set as leader and follower rate on the brokers of the reassigned partitions
+	Rate int64
+
+	// Wait configures the wait for the end of the reassignment, the throttle is left in place when it fails
+	Wait ReassignmentWaitOptions
+}
+
+// UpdateReplicationsFactorThrottled is UpdateReplicationsFactor with the replication throttled while the new replicas
+// are copied. It blocks until the reassignment is completed and then removes the throttle. t.ClusterID is required
+// as the throttle is set through the REST API, RemoveReplicationThrottle removes a throttle left by a failed wait.
+// The throttled replicas of the topic set before are replaced.
+func (c *Client) UpdateReplicationsFactorThrottled(t Topic, throttle ReplicationThrottle) error {
+	if t.ClusterID == "" {
+		return errors.New("ClusterID is required to throttle the replication")
+	}
+
+	plan, err := c.PlanReplicationsFactor(t)
+	if err != nil {
+		return err
+	}
+	return c.ExecuteReplicationsFactorPlan(plan, &throttle)
+}
+
+func (c *Client) executeThrottled(plan *ReplicationFactorPlan, throttle ReplicationThrottle) error {
+	if plan.ClusterID == "" {
+		return errors.New("ClusterID is required to throttle the replication")
+	}
+	if throttle.Rate <= 0 {
+		return fmt.Errorf("invalid throttle rate %d", throttle.Rate)
+	}
+
+	leaderReplicas, followerReplicas, brokers, moved := throttledReplicas(plan)
+	if len(followerReplicas) == 0 {
+		// Nothing to copy
+		return c.ExecuteReplicationsFactorPlan(plan, nil)
+	}
+
+	if err := c.setReplicationThrottle(plan.ClusterID, plan.TopicName, throttle.Rate, leaderReplicas, followerReplicas, brokers); err != nil {
+		return err
+	}
+	if err := c.ExecuteReplicationsFactorPlan(plan, nil); err != nil {
+		if removeErr := c.RemoveReplicationThrottle(plan.ClusterID, plan.TopicName, brokers); removeErr != nil {
+			return fmt.Errorf("%w, the replication throttle was not removed: %s", err, removeErr)
+		}
+		return err
+	}
+
+	if err := c.WaitForPartitionReassignments(plan.ClusterID, plan.TopicName, moved, throttle.Wait); err != nil {
+		return err
+	}
+	return c.RemoveReplicationThrottle(plan.ClusterID, plan.TopicName, brokers)
+}
+
+// RemoveReplicationThrottle removes the throttled replicas of the topic and the throttle rates of the brokers
+func (c *Client) RemoveReplicationThrottle(clusterId, topicName string, brokerIds []int32) error {
+	errs := make([]error, 0)
+	err := c.UpdateTopicConfigs(clusterId, topicName, []TopicConfig{
+		{Name: configLeaderThrottledReplicas, Operation: configOperationDelete},
+		{Name: configFollowerThrottledReplicas, Operation: configOperationDelete},
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, b := range brokerIds {
+		err := c.UpdateBrokerConfigs(clusterId, b, []TopicConfig{
+			{Name: configLeaderThrottledRate, Operation: configOperationDelete},
+			{Name: configFollowerThrottledRate, Operation: configOperationDelete},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("broker %d: %w", b, err))
+		}
+	}
+
+	if len(errs) != 0 {
+		return errors.New(sarama.MultiError{Errors: &errs}.PrettyError())
+	}
+	return nil
+}
+
+func (c *Client) setReplicationThrottle(clusterId, topicName string, rate int64, leaderReplicas, followerReplicas []string, brokerIds []int32) error {
+	value := strconv.FormatInt(rate, 10)
+	for _, b := range brokerIds {
+		err := c.UpdateBrokerConfigs(clusterId, b, []TopicConfig{
+			{Name: configLeaderThrottledRate, Value: value},
+			{Name: configFollowerThrottledRate, Value: value},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.UpdateTopicConfigs(clusterId, topicName, []TopicConfig{
+		{Name: configLeaderThrottledReplicas, Value: strings.Join(leaderReplicas, ",")},
+		{Name: configFollowerThrottledReplicas, Value: strings.Join(followerReplicas, ",")},
+	})
+}
+
+// throttledReplicas returns the current replicas of the moved partitions, which are throttled as leaders, the new
+// replicas, which are throttled as followers, as "partition:broker", the brokers of the moved partitions and the
+// moved partitions
+func throttledReplicas(plan *ReplicationFactorPlan) ([]string, []string, []int32, []int32) {
+	leaderReplicas := make([]string, 0)
+	followerReplicas := make([]string, 0)
+	brokers := make(map[int32]bool)
+	moved := make([]int32, 0)
+
+	for _, p := range plan.Partitions {
+		if !p.Changed() {
+			continue
+		}
+		moved = append(moved, p.Partition)
+
+		used := make(map[int32]bool, len(p.Before))
+		for _, r := range p.Before {
+			used[r] = true
+			brokers[r] = true
+			leaderReplicas = append(leaderReplicas, fmt.Sprintf("%d:%d", p.Partition, r))
+		}
+		for _, r := range p.After {
+			if !used[r] {
+				brokers[r] = true
+				followerReplicas = append(followerReplicas, fmt.Sprintf("%d:%d", p.Partition, r))
+			}
+		}
+	}
+
+	brokerIds := make([]int32, 0, len(brokers))
+	for b := range brokers {
+		brokerIds = append(brokerIds, b)
+	}
+	sort.Slice(brokerIds, func(i, j int) bool { return brokerIds[i] < brokerIds[j] })
+	return leaderReplicas, followerReplicas, brokerIds, moved
+}
@@ -0,0 +1,87 @@
+package confluent
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle_UpdateReplicationsFactorThrottled(t *testing.T) {
+	requests := make([]string, 0)
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodPost, method)
+		b, _ := ioutil.ReadAll(reqBody)
+		requests = append(requests, method+" "+uri+" "+strings.TrimSpace(string(b)))
+		return nil, 204, "204 No Content", nil
+	}
+
+	// The mock has the partitions 1 and 2, the partition 0 is left untouched
+	mk := newRebalanceMockClient(map[int32]string{1: "", 2: "", 3: ""}, map[string][][]int32{"topic-1": {{1}, {2}}})
+	mk.ReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		if partitionId == 0 {
+			return []int32{3}, nil
+		}
+		return []int32{partitionId}, nil
+	}
+	reassigning := false
+	var altered [][]int32
+	admin := &MockKafkaAdmin{}
+	admin.AlterPartitionReassignmentsFn = func(topic string, assignment [][]int32) error {
+		altered = assignment
+		reassigning = true
+		return nil
+	}
+	admin.ListPartitionReassignmentsFn = func(topic string, partitions []int32) (map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus, error) {
+		if reassigning {
+			// Completed at the second check
+			reassigning = false
+			return map[string]map[int32]*sarama.PartitionReplicaReassignmentsStatus{
+				topic: {2: {Replicas: []int32{2, 3}, AddingReplicas: []int32{3}}},
+			}, nil
+		}
+		return nil, nil
+	}
+	c := NewClient(&mock, mk, admin)
+
+	err := c.UpdateReplicationsFactorThrottled(
+		Topic{ClusterID: clusterId, Name: "topic-1", ReplicationFactor: 2},
+		ReplicationThrottle{Rate: 10485760, Wait: ReassignmentWaitOptions{Interval: 1}},
+	)
+	if assert.NoError(t, err) {
+		assert.Equal(t, [][]int32{{3}, {1, 3}, {2, 3}}, altered)
+		assert.Equal(t, []string{
+			`POST /kafka/v3/clusters/cluster-1/brokers/1/configs:alter {"data":[{"name":"leader.replication.throttled.rate","value":"10485760"},{"name":"follower.replication.throttled.rate","value":"10485760"}]}`,
+			`POST /kafka/v3/clusters/cluster-1/brokers/2/configs:alter {"data":[{"name":"leader.replication.throttled.rate","value":"10485760"},{"name":"follower.replication.throttled.rate","value":"10485760"}]}`,
+			`POST /kafka/v3/clusters/cluster-1/brokers/3/configs:alter {"data":[{"name":"leader.replication.throttled.rate","value":"10485760"},{"name":"follower.replication.throttled.rate","value":"10485760"}]}`,
+			`POST /kafka/v3/clusters/cluster-1/topics/topic-1/configs:alter {"data":[{"name":"leader.replication.throttled.replicas","value":"1:1,2:2"},{"name":"follower.replication.throttled.replicas","value":"1:3,2:3"}]}`,
+			`POST /kafka/v3/clusters/cluster-1/topics/topic-1/configs:alter {"data":[{"name":"leader.replication.throttled.replicas","value":"","operation":"DELETE"},{"name":"follower.replication.throttled.replicas","value":"","operation":"DELETE"}]}`,
+			`POST /kafka/v3/clusters/cluster-1/brokers/1/configs:alter {"data":[{"name":"leader.replication.throttled.rate","value":"","operation":"DELETE"},{"name":"follower.replication.throttled.rate","value":"","operation":"DELETE"}]}`,
+			`POST /kafka/v3/clusters/cluster-1/brokers/2/configs:alter {"data":[{"name":"leader.replication.throttled.rate","value":"","operation":"DELETE"},{"name":"follower.replication.throttled.rate","value":"","operation":"DELETE"}]}`,
+			`POST /kafka/v3/clusters/cluster-1/brokers/3/configs:alter {"data":[{"name":"leader.replication.throttled.rate","value":"","operation":"DELETE"},{"name":"follower.replication.throttled.rate","value":"","operation":"DELETE"}]}`,
+		}, requests)
+	}
+
+	err = c.UpdateReplicationsFactorThrottled(Topic{Name: "topic-1", ReplicationFactor: 2}, ReplicationThrottle{Rate: 1})
+	assert.Equal(t, errors.New("ClusterID is required to throttle the replication"), err)
+}
+
+func TestThrottle_ThrottledReplicas(t *testing.T) {
+	plan := &ReplicationFactorPlan{Partitions: []PartitionReplicasPlan{
+		{Partition: 0, Before: []int32{1, 2}, After: []int32{1, 2}},
+		{Partition: 1, Before: []int32{2, 3}, After: []int32{2, 3, 4}},
+		{Partition: 2, Before: []int32{3, 1}, After: []int32{3}},
+	}}
+
+	leaders, followers, brokers, moved := throttledReplicas(plan)
+	assert.Equal(t, []string{"1:2", "1:3", "2:3", "2:1"}, leaders)
+	assert.Equal(t, []string{"1:4"}, followers)
+	assert.Equal(t, []int32{1, 2, 3, 4}, brokers)
+	assert.Equal(t, []int32{1, 2}, moved)
+}
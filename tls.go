@@ -0,0 +1,220 @@
+package confluent
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+
+	errIncorrectPassphrase = errors.New("[ERROR] Unable to decrypt the client key: incorrect ClientCertKeyPassphrase")
+)
+
+// ASN.1 structures of an encrypted PKCS#8 key, see RFC 5208 and RFC 8018
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkixAlgorithm
+	EncryptedData []byte
+}
+
+type pkixAlgorithm struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkixAlgorithm
+	EncryptionScheme  pkixAlgorithm
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int           `asn1:"optional"`
+	PRF            pkixAlgorithm `asn1:"optional"`
+}
+
+// loadX509KeyPair loads the client certificate and key, each given inline as PEM or as the path of a PEM file.
+// The key may be encrypted with the passphrase, either as a legacy encrypted PEM block or as an encrypted PKCS#8 key.
+func loadX509KeyPair(clientCert, clientKey, clientKeyPassphrase string) (tls.Certificate, error) {
+	_, certBytes, err := parsePemOrLoadFromFile(clientCert)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("[ERROR] Unable to load the client certificate: %w", err)
+	}
+
+	_, keyBytes, err := parsePemOrLoadFromFile(clientKey)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("[ERROR] Unable to load the client key: %w", err)
+	}
+
+	keyBlock := findPrivateKeyBlock(keyBytes)
+	if keyBlock == nil {
+		return tls.Certificate{}, errors.New("[ERROR] No private key found in the client key")
+	}
+
+	keyBlock, err = decryptPrivateKeyBlock(keyBlock, clientKeyPassphrase)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	cert, err := tls.X509KeyPair(certBytes, pem.EncodeToMemory(keyBlock))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("[ERROR] Client certificate and key do not match: %w", err)
+	}
+	return cert, nil
+}
+
+// findPrivateKeyBlock skips the blocks preceding the key, such as EC PARAMETERS
+func findPrivateKeyBlock(pemBytes []byte) *pem.Block {
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			return nil
+		}
+		if block.Type == "ENCRYPTED PRIVATE KEY" || block.Type == "PRIVATE KEY" ||
+			block.Type == "RSA PRIVATE KEY" || block.Type == "EC PRIVATE KEY" {
+			return block
+		}
+	}
+}
+
+func decryptPrivateKeyBlock(block *pem.Block, passphrase string) (*pem.Block, error) {
+	// Legacy encrypted PEM (Proc-Type: 4,ENCRYPTED) is deprecated but still issued by some PKIs
+	legacyEncrypted := x509.IsEncryptedPEMBlock(block)
+	if !legacyEncrypted && block.Type != "ENCRYPTED PRIVATE KEY" {
+		return block, nil
+	}
+	if passphrase == "" {
+		return nil, errors.New("[ERROR] The client key is encrypted but no ClientCertKeyPassphrase is provided")
+	}
+
+	if legacyEncrypted {
+		der, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+		if err != nil {
+			return nil, errIncorrectPassphrase
+		}
+		return &pem.Block{Type: block.Type, Bytes: der}, nil
+	}
+
+	der, err := decryptPKCS8(block.Bytes, []byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+}
+
+// decryptPKCS8 decrypts a PKCS#8 key encrypted with PBES2, the scheme used by openssl and keytool
+func decryptPKCS8(der []byte, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("[ERROR] Unable to parse the encrypted client key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("[ERROR] Unsupported client key encryption %s, only PBES2 is supported", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("[ERROR] Unable to parse the client key encryption parameters: %w", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("[ERROR] Unsupported client key derivation %s, only PBKDF2 is supported", params.KeyDerivationFunc.Algorithm)
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("[ERROR] Unable to parse the client key derivation parameters: %w", err)
+	}
+
+	var prf func() hash.Hash
+	switch {
+	case len(kdf.PRF.Algorithm) == 0, kdf.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdf.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	case kdf.PRF.Algorithm.Equal(oidHMACWithSHA512):
+		prf = sha512.New
+	default:
+		return nil, fmt.Errorf("[ERROR] Unsupported client key derivation function %s", kdf.PRF.Algorithm)
+	}
+
+	var keyLength int
+	var newCipher func(key []byte) (cipher.Block, error)
+	switch scheme := params.EncryptionScheme.Algorithm; {
+	case scheme.Equal(oidAES128CBC):
+		keyLength, newCipher = 16, aes.NewCipher
+	case scheme.Equal(oidAES192CBC):
+		keyLength, newCipher = 24, aes.NewCipher
+	case scheme.Equal(oidAES256CBC):
+		keyLength, newCipher = 32, aes.NewCipher
+	case scheme.Equal(oidDESEDE3CBC):
+		keyLength, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, fmt.Errorf("[ERROR] Unsupported client key cipher %s", scheme)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("[ERROR] Unable to parse the client key cipher parameters: %w", err)
+	}
+
+	key := pbkdf2.Key(passphrase, kdf.Salt, kdf.IterationCount, keyLength, prf)
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, errors.New("[ERROR] Invalid encrypted client key")
+	}
+
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	// A wrong passphrase almost always shows up as an invalid padding or an unparsable key
+	decrypted, err = unpadPKCS7(decrypted, block.BlockSize())
+	if err != nil {
+		return nil, errIncorrectPassphrase
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(decrypted); err != nil {
+		return nil, errIncorrectPassphrase
+	}
+	return decrypted, nil
+}
+
+func unpadPKCS7(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty data")
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return data[:len(data)-n], nil
+}
@@ -4,10 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/Shopify/sarama"
 	"io"
-	"math/rand"
+	"sort"
 	"time"
 )
 
@@ -47,20 +46,24 @@ func (c *Client) DoRequest(method string, uri string, reqBody io.Reader) ([]byte
 		return respBody, err
 	}
 	if statusCode > 204 {
-		var errorBody *ErrorResponse
-		err = json.Unmarshal(respBody, &errorBody)
-		if err != nil {
-			return nil, errors.New("error with status: " + status)
-		}
-
-		if errorBody.Errors != nil {
-			return nil, errors.New("error with status: " + status + " " + errorBody.Errors[0].Message)
-		}
-		return nil, errors.New("error with status: " + status + " " + errorBody.Message)
+		return nil, parseErrorResponse(respBody, status)
 	}
 	return respBody, nil
 }
 
+func parseErrorResponse(respBody []byte, status string) error {
+	var errorBody *ErrorResponse
+	err := json.Unmarshal(respBody, &errorBody)
+	if err != nil {
+		return errors.New("error with status: " + status)
+	}
+
+	if errorBody.Errors != nil {
+		return errors.New("error with status: " + status + " " + errorBody.Errors[0].Message)
+	}
+	return errors.New("error with status: " + status + " " + errorBody.Message)
+}
+
 func (c *Client) ListTopics(clusterId string) ([]Topic, error) {
 	uri := "/kafka/v3/clusters/" + clusterId + "/" + topicPath
 	r, err := c.DoRequest("GET", uri, nil)
@@ -158,7 +161,13 @@ func (c *Client) DeleteTopic(clusterId, topicName string) error {
 	return nil
 }
 
+// UpdatePartitions increases the partitions count of the topic. Without kafka client, the topic of the
+// cluster t.ClusterID is updated through the REST API.
 func (c *Client) UpdatePartitions(t Topic) error {
+	if c.saramaClient == nil {
+		return c.updatePartitionsRest(t)
+	}
+
 	broker, err := c.saramaClient.Controller()
 	if err != nil {
 		return err
@@ -188,18 +197,184 @@ func (c *Client) UpdatePartitions(t Topic) error {
 	return err
 }
 
-func (c *Client) UpdateReplicationsFactor(t Topic) error {
-	if err := c.saramaClient.RefreshMetadata(); err != nil {
+// @ref https://docs.confluent.io/platform/current/kafka-rest/api.html#patch--clusters-cluster_id-topics-topic_name
+func (c *Client) updatePartitionsRest(t Topic) error {
+	if t.ClusterID == "" {
+		return errors.New("ClusterID is required to update the partitions without kafka client")
+	}
+
+	payloadBuf := new(bytes.Buffer)
+	err := json.NewEncoder(payloadBuf).Encode(struct {
+		PartitionsCount int32 `json:"partitions_count"`
+	}{t.Partitions})
+	if err != nil {
 		return err
 	}
-	assignment, err := c.buildAssignment(t)
+
+	uri := "/kafka/v3/clusters/" + t.ClusterID + "/" + topicPath + "/" + t.Name
+	_, err = c.DoRequest("PATCH", uri, payloadBuf)
+	return err
+}
+
+// PartitionReplicasPlan is the change of the replicas of a partition, Leader and InSyncReplicas are the state of the
+// partition when the plan was built, Leader is -1 when the partition has no leader
+type PartitionReplicasPlan struct {
+	Partition      int32
+	Before         []int32
+	After          []int32
+	Leader         int32
+	InSyncReplicas []int32
+}
+
+// Changed tells whether the partition is reassigned
+func (p PartitionReplicasPlan) Changed() bool {
+	return !equalReplicas(p.Before, p.After)
+}
+
+// ReplicationFactorPlan is the reassignment of the partitions of a topic to change its replication factor
+type ReplicationFactorPlan struct {
+	ClusterID         string
+	TopicName         string
+	ReplicationFactor int16
+	// Partitions are sorted by partition id
+	Partitions []PartitionReplicasPlan
+}
+
+// UpdateReplicationsFactor reassigns the partitions of the topic to reach the replication factor. It requires a kafka
+// client as the REST API cannot reassign the partitions, PlanReplicationsFactor still plans the change without it.
+func (c *Client) UpdateReplicationsFactor(t Topic) error {
+	plan, err := c.PlanReplicationsFactor(t)
 	if err != nil {
 		return err
 	}
-	return c.saramaClusterAdmin.AlterPartitionReassignments(t.Name, *assignment)
+	return c.ExecuteReplicationsFactorPlan(plan, nil)
+}
+
+// PlanReplicationsFactor returns the replicas of the partitions of the topic before and after changing its
+// replication factor, without changing them. New replicas are placed on the least loaded brokers of the racks with the
+// fewest replicas of the partition. Decreasing the replication factor drops the out-of-sync replicas first, even the
+// preferred leader, then the in-sync ones, the in-sync current leader last.
+func (c *Client) PlanReplicationsFactor(t Topic) (*ReplicationFactorPlan, error) {
+	if c.saramaClient == nil || c.saramaClusterAdmin == nil {
+		return c.planReplicationsFactorRest(t)
+	}
+
+	if err := c.saramaClient.RefreshMetadata(); err != nil {
+		return nil, err
+	}
+	partitions, err := c.saramaClient.Partitions(t.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	loads, err := c.brokerLoads()
+	if err != nil {
+		return nil, err
+	}
+
+	placer := newReplicaPlacer(loads, c.placementSeed)
+	return assignReplicas(t, partitions, placer, func(partitionId int32) (*PartitionReplicasPlan, error) {
+		replicas, err := c.saramaClient.Replicas(t.Name, partitionId)
+		if err != nil {
+			return nil, err
+		}
+		isr, err := c.saramaClient.InSyncReplicas(t.Name, partitionId)
+		if err != nil {
+			return nil, err
+		}
+		leader := int32(-1)
+		broker, err := c.saramaClient.Leader(t.Name, partitionId)
+		if err != nil && err != sarama.ErrLeaderNotAvailable {
+			return nil, err
+		}
+		if broker != nil {
+			leader = c.saramaClient.ID(broker)
+		}
+		return &PartitionReplicasPlan{Partition: partitionId, Before: replicas, Leader: leader, InSyncReplicas: isr}, nil
+	})
+}
+
+func (c *Client) planReplicationsFactorRest(t Topic) (*ReplicationFactorPlan, error) {
+	if t.ClusterID == "" {
+		return nil, errors.New("ClusterID is required to update the replication factor without kafka client")
+	}
+
+	loads, err := c.brokerLoadsRest(t.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions, err := c.GetTopicPartitions(t.ClusterID, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	partitionIds := make([]int32, 0, len(partitions))
+	for _, p := range partitions {
+		partitionIds = append(partitionIds, int32(p.PartitionId))
+	}
+
+	placer := newReplicaPlacer(loads, c.placementSeed)
+	return assignReplicas(t, partitionIds, placer, func(partitionId int32) (*PartitionReplicasPlan, error) {
+		replicas, err := c.GetPartitionReplicas(t.ClusterID, t.Name, int(partitionId))
+		if err != nil {
+			return nil, err
+		}
+		p := &PartitionReplicasPlan{
+			Partition:      partitionId,
+			Before:         make([]int32, 0, len(replicas)),
+			Leader:         -1,
+			InSyncReplicas: make([]int32, 0, len(replicas)),
+		}
+		for _, r := range replicas {
+			p.Before = append(p.Before, r.BrokerID)
+			if r.IsLeader {
+				p.Leader = r.BrokerID
+			}
+			if r.IsInSync {
+				p.InSyncReplicas = append(p.InSyncReplicas, r.BrokerID)
+			}
+		}
+		return p, nil
+	})
+}
+
+// ExecuteReplicationsFactorPlan reassigns the changed partitions of the plan, throttling the replication when
+// throttle is not nil, see UpdateReplicationsFactorThrottled
+func (c *Client) ExecuteReplicationsFactorPlan(plan *ReplicationFactorPlan, throttle *ReplicationThrottle) error {
+	if c.saramaClient == nil || c.saramaClusterAdmin == nil {
+		return errors.New("UpdateReplicationsFactor requires a kafka client")
+	}
+	if throttle != nil {
+		return c.executeThrottled(plan, *throttle)
+	}
+
+	assignment := plan.assignment()
+	if len(assignment) == 0 {
+		return nil
+	}
+	return c.AlterPartitionReassignments(plan.ClusterID, plan.TopicName, assignment)
 }
 
+// assignment returns the new replicas of the changed partitions
+func (plan *ReplicationFactorPlan) assignment() map[int32][]int32 {
+	assignment := make(map[int32][]int32)
+	for _, p := range plan.Partitions {
+		if p.Changed() {
+			assignment[p.Partition] = p.After
+		}
+	}
+	return assignment
+}
+
+// IsReplicationFactorUpdating tells whether replicas of the partitions of the topic are being added or removed, see
+// ListPartitionReassignments to list them through the REST API without kafka client
 func (c *Client) IsReplicationFactorUpdating(topic string) (bool, error) {
+	if c.saramaClient == nil || c.saramaClusterAdmin == nil {
+		return false, errors.New("IsReplicationFactorUpdating requires a kafka client")
+	}
+	if err := c.requireAPI(apiKeyListPartitionReassignments, 0, "ListPartitionReassignments"); err != nil {
+		return false, err
+	}
 	if err := c.saramaClient.RefreshMetadata(); err != nil {
 		return false, err
 	}
@@ -223,100 +398,120 @@ func (c *Client) IsReplicationFactorUpdating(topic string) (bool, error) {
 	return false, nil
 }
 
-func (c *Client) allReplicas() *[]int32 {
-	brokers := c.saramaClient.Brokers()
-	replicas := make([]int32, 0, len(brokers))
-
-	for _, b := range brokers {
-		id := c.saramaClient.ID(b)
-		fmt.Println(id)
-		if id != -1 {
-			replicas = append(replicas, id)
-		}
-	}
-
-	return &replicas
-}
-
-func (c *Client) buildAssignment(t Topic) (*[][]int32, error) {
-	partitions, err := c.saramaClient.Partitions(t.Name)
-	if err != nil {
-		return nil, err
+// assignReplicas plans the new replicas of each partition, partitionState returns the current state of a partition
+func assignReplicas(t Topic, partitions []int32, placer *replicaPlacer, partitionState func(partitionId int32) (*PartitionReplicasPlan, error)) (*ReplicationFactorPlan, error) {
+	sorted := make([]int32, len(partitions))
+	copy(sorted, partitions)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	plan := &ReplicationFactorPlan{
+		ClusterID:         t.ClusterID,
+		TopicName:         t.Name,
+		ReplicationFactor: t.ReplicationFactor,
+		Partitions:        make([]PartitionReplicasPlan, 0, len(sorted)),
 	}
-
-	allReplicas := c.allReplicas()
-	newRF := t.ReplicationFactor
-	rand.Seed(time.Now().UnixNano())
-
-	assignment := make([][]int32, len(partitions))
-	for _, p := range partitions {
-		oldReplicas, err := c.saramaClient.Replicas(t.Name, p)
+	for _, p := range sorted {
+		state, err := partitionState(p)
 		if err != nil {
-			return &assignment, err
+			return nil, err
 		}
 
-		oldRF := int16(len(oldReplicas))
-		deltaRF := int16(newRF) - oldRF
-		newReplicas, err := buildNewReplicas(allReplicas, &oldReplicas, deltaRF)
+		deltaRF := t.ReplicationFactor - int16(len(state.Before))
+		newReplicas, err := buildNewReplicas(placer, state, deltaRF)
 		if err != nil {
-			return &assignment, err
+			return nil, err
 		}
 
-		assignment[p] = *newReplicas
+		state.After = *newReplicas
+		plan.Partitions = append(plan.Partitions, *state)
 	}
 
-	return &assignment, nil
+	return plan, nil
+}
+
+func equalReplicas(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 func isPartitionRFChanging(status *sarama.PartitionReplicaReassignmentsStatus) bool {
 	return len(status.AddingReplicas) != 0 || len(status.RemovingReplicas) != 0
 }
 
-func buildNewReplicas(allReplicas *[]int32, usedReplicas *[]int32, deltaRF int16) (*[]int32, error) {
-	usedCount := int16(len(*usedReplicas))
+func buildNewReplicas(placer *replicaPlacer, state *PartitionReplicasPlan, deltaRF int16) (*[]int32, error) {
+	usedReplicas := state.Before
+	usedCount := int16(len(usedReplicas))
 
 	if deltaRF == 0 {
-		return usedReplicas, nil
+		return &usedReplicas, nil
 	} else if deltaRF < 0 {
-		end := usedCount + deltaRF
-		if end < 1 {
+		if usedCount+deltaRF < 1 {
 			return nil, errors.New("dropping too many replicas")
 		}
 
-		head := (*usedReplicas)[:end]
-		return &head, nil
+		kept, dropped := dropReplicas(state, int(-deltaRF))
+		placer.release(dropped)
+		return &kept, nil
 	} else {
-		extraCount := int16(len(*allReplicas)) - usedCount
-		if extraCount < deltaRF {
-			return nil, errors.New("not enough brokers")
-		}
-
-		unusedReplicas := *findUnusedReplicas(allReplicas, usedReplicas, extraCount)
-		newReplicas := *usedReplicas
-		for i := int16(0); i < deltaRF; i++ {
-			j := rand.Intn(len(unusedReplicas))
-			newReplicas = append(newReplicas, unusedReplicas[j])
-			unusedReplicas[j] = unusedReplicas[len(unusedReplicas)-1]
-			unusedReplicas = unusedReplicas[:len(unusedReplicas)-1]
+		newReplicas, err := placer.place(usedReplicas, int(deltaRF))
+		if err != nil {
+			return nil, err
 		}
-
 		return &newReplicas, nil
 	}
 }
 
-func findUnusedReplicas(allReplicas *[]int32, usedReplicas *[]int32, extraCount int16) *[]int32 {
-	usedMap := make(map[int32]bool, len(*usedReplicas))
-	for _, r := range *usedReplicas {
-		usedMap[r] = true
+// dropReplicas drops the out-of-sync replicas first, then the in-sync ones, the last replicas first. The current leader
+// ranks above the other in-sync replicas when it is in sync itself, so an in-sync replica is kept as long as one is
+// left. The kept replicas keep their order.
+func dropReplicas(state *PartitionReplicasPlan, count int) ([]int32, []int32) {
+	inSync := make(map[int32]bool, len(state.InSyncReplicas))
+	for _, r := range state.InSyncReplicas {
+		inSync[r] = true
 	}
-
-	unusedReplicas := make([]int32, 0, extraCount)
-	for _, r := range *allReplicas {
-		_, exists := usedMap[r]
-		if !exists {
-			unusedReplicas = append(unusedReplicas, r)
+	rank := func(i int) int {
+		r := state.Before[i]
+		switch {
+		case inSync[r] && r == state.Leader:
+			return 3
+		case inSync[r]:
+			return 2
+		case r == state.Leader:
+			return 1
 		}
+		return 0
+	}
+
+	order := make([]int, len(state.Before))
+	for i := range order {
+		order[i] = i
 	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if rank(order[i]) != rank(order[j]) {
+			return rank(order[i]) < rank(order[j])
+		}
+		return order[i] > order[j]
+	})
 
-	return &unusedReplicas
+	drop := make(map[int]bool, count)
+	for _, i := range order[:count] {
+		drop[i] = true
+	}
+	kept := make([]int32, 0, len(state.Before)-count)
+	dropped := make([]int32, 0, count)
+	for i, r := range state.Before {
+		if drop[i] {
+			dropped = append(dropped, r)
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	return kept, dropped
 }
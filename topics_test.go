@@ -4,7 +4,9 @@ import (
 	"errors"
 	"github.com/Shopify/sarama"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -188,6 +190,9 @@ func TestTopics_GetUpdatingSuccess(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	_, err = NewClient(&mock, nil, nil).IsReplicationFactorUpdating(mockTopic.Name)
+	assert.Equal(t, errors.New("IsReplicationFactorUpdating requires a kafka client"), err)
 }
 
 func TestTopics_IsPartitionRFChanging(t *testing.T) {
@@ -206,31 +211,173 @@ func TestTopics_IsPartitionRFChanging(t *testing.T) {
 }
 
 func TestTopics_BuildNewReplicas(t *testing.T) {
-	allReplicas := []int32{1, 2, 3, 4}
-	usedReplicas := []int32{5, 6, 7}
+	placer := newReplicaPlacer([]brokerLoad{{id: 1}, {id: 2, replicas: 1}, {id: 3}, {id: 4}}, 0)
+	usedReplicas := []int32{1, 3, 4}
+	state := &PartitionReplicasPlan{Before: usedReplicas, Leader: 1, InSyncReplicas: usedReplicas}
 	deltaRF := int16(0)
 
-	b,_ := buildNewReplicas(&allReplicas, &usedReplicas, deltaRF)
+	b,_ := buildNewReplicas(placer, state, deltaRF)
 	assert.Equal(t, &usedReplicas, b)
 
 	deltaRF = int16(-4)
-	_, err := buildNewReplicas(&allReplicas, &usedReplicas, deltaRF)
+	_, err := buildNewReplicas(placer, state, deltaRF)
 	assert.NotNil(t, err)
 	assert.Equal(t, errors.New("dropping too many replicas"), err)
 
 	deltaRF = int16(-1)
-	b, err = buildNewReplicas(&allReplicas, &usedReplicas, deltaRF)
+	b, err = buildNewReplicas(placer, state, deltaRF)
 	assert.Nil(t, err)
 	assert.Equal(t, usedReplicas[:2], *b)
 
 	deltaRF = int16(1)
-	b, err = buildNewReplicas(&allReplicas, &usedReplicas, deltaRF)
+	b, err = buildNewReplicas(placer, state, deltaRF)
 	assert.Nil(t, err)
-	assert.Equal(t, []int32{5, 6, 7, 2}, *b)
+	assert.Equal(t, []int32{1, 3, 4, 2}, *b)
 
 	deltaRF = int16(2)
-	b, err = buildNewReplicas(&allReplicas, &usedReplicas, deltaRF)
+	b, err = buildNewReplicas(placer, state, deltaRF)
 	assert.NotNil(t, err)
 	assert.Equal(t, errors.New("not enough brokers"), err)
 }
 
+func TestTopics_DropReplicas(t *testing.T) {
+	// The out-of-sync replicas are dropped first
+	kept, dropped := dropReplicas(&PartitionReplicasPlan{Before: []int32{1, 2, 3, 4}, Leader: 1, InSyncReplicas: []int32{1, 3, 4}}, 1)
+	assert.Equal(t, []int32{1, 3, 4}, kept)
+	assert.Equal(t, []int32{2}, dropped)
+
+	// The current leader is kept
+	kept, _ = dropReplicas(&PartitionReplicasPlan{Before: []int32{1, 2, 3}, Leader: 3, InSyncReplicas: []int32{1, 2, 3}}, 1)
+	assert.Equal(t, []int32{1, 3}, kept)
+
+	// The out-of-sync preferred replica is dropped, the leader and the in-sync replicas are kept
+	kept, dropped = dropReplicas(&PartitionReplicasPlan{Before: []int32{1, 2, 3}, Leader: 2, InSyncReplicas: []int32{2, 3}}, 2)
+	assert.Equal(t, []int32{2}, kept)
+	assert.Equal(t, []int32{1, 3}, dropped)
+
+	kept, _ = dropReplicas(&PartitionReplicasPlan{Before: []int32{1, 2, 3}, Leader: 2, InSyncReplicas: []int32{2, 3}}, 1)
+	assert.Equal(t, []int32{2, 3}, kept)
+
+	// The only in-sync replica is never dropped
+	kept, _ = dropReplicas(&PartitionReplicasPlan{Before: []int32{1, 2, 3}, Leader: 2, InSyncReplicas: []int32{2}}, 2)
+	assert.Equal(t, []int32{2}, kept)
+
+	kept, _ = dropReplicas(&PartitionReplicasPlan{Before: []int32{1, 2}, Leader: 1, InSyncReplicas: []int32{2}}, 1)
+	assert.Equal(t, []int32{2}, kept)
+
+	// Without leader, the in-sync replicas are kept first
+	kept, _ = dropReplicas(&PartitionReplicasPlan{Before: []int32{1, 2, 3}, Leader: -1, InSyncReplicas: []int32{3}}, 2)
+	assert.Equal(t, []int32{3}, kept)
+}
+
+func TestTopics_PlanReplicationsFactorOutOfSyncPreferredLeader(t *testing.T) {
+	mk := MockKafkaClient{}
+	mk.ReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		return []int32{1, 2, 3}, nil
+	}
+	mk.InSyncReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		return []int32{2, 3}, nil
+	}
+	mk.LeaderFn = func(topic string, partitionId int32) (*sarama.Broker, error) {
+		return sarama.NewBroker("broker-3:9092"), nil
+	}
+	mk.IDFn = func(broker *sarama.Broker) int32 {
+		return int32(broker.Addr()[7] - '0')
+	}
+	c := NewClient(&MockHttpClient{}, &mk, &MockKafkaAdmin{})
+
+	// The out-of-sync preferred leader is dropped first, the in-sync leader last
+	plan, err := c.PlanReplicationsFactor(Topic{Name: "topic-1", ReplicationFactor: 2})
+	if assert.NoError(t, err) && assert.Len(t, plan.Partitions, 2) {
+		assert.Equal(t, []int32{2, 3}, plan.Partitions[0].After)
+	}
+
+	plan, err = c.PlanReplicationsFactor(Topic{Name: "topic-1", ReplicationFactor: 1})
+	if assert.NoError(t, err) && assert.Len(t, plan.Partitions, 2) {
+		assert.Equal(t, []int32{3}, plan.Partitions[0].After)
+	}
+}
+
+func TestTopics_PlanReplicationsFactorWithSparsePartitions(t *testing.T) {
+	mk := MockKafkaClient{}
+	mk.ReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		return []int32{partitionId, 3, 4}, nil
+	}
+	mk.InSyncReplicasFn = func(topic string, partitionId int32) ([]int32, error) {
+		return []int32{partitionId, 3}, nil
+	}
+	admin := &MockKafkaAdmin{TopicNameExpected: "topic-1"}
+	var altered [][]int32
+	admin.AlterPartitionReassignmentsFn = func(topic string, assignment [][]int32) error {
+		altered = assignment
+		return nil
+	}
+	c := NewClient(&MockHttpClient{}, &mk, admin)
+
+	// The mock has the partitions 1 and 2
+	plan, err := c.PlanReplicationsFactor(Topic{ClusterID: clusterId, Name: "topic-1", ReplicationFactor: 2})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []PartitionReplicasPlan{
+			{Partition: 1, Before: []int32{1, 3, 4}, After: []int32{1, 3}, Leader: -1, InSyncReplicas: []int32{1, 3}},
+			{Partition: 2, Before: []int32{2, 3, 4}, After: []int32{2, 3}, Leader: -1, InSyncReplicas: []int32{2, 3}},
+		}, plan.Partitions)
+	}
+
+	if assert.NoError(t, c.ExecuteReplicationsFactorPlan(plan, nil)) {
+		// The partition 0 keeps its replicas
+		assert.Equal(t, [][]int32{{0, 3, 4}, {1, 3}, {2, 3}}, altered)
+	}
+}
+
+func TestTopics_UpdatePartitionsWithoutKafkaClient(t *testing.T) {
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodPatch, method)
+		assert.Equal(t, "/kafka/v3/clusters/cluster-1/topics/topic-1", uri)
+		body, _ := ioutil.ReadAll(reqBody)
+		assert.JSONEq(t, `{"partitions_count": 6}`, string(body))
+		return nil, 204, "204 No Content", nil
+	}
+	c := NewClient(&mock, nil, nil)
+
+	err := c.UpdatePartitions(Topic{ClusterID: clusterId, Name: "topic-1", Partitions: 6})
+	assert.NoError(t, err)
+
+	err = c.UpdatePartitions(Topic{Name: "topic-1", Partitions: 6})
+	assert.Equal(t, errors.New("ClusterID is required to update the partitions without kafka client"), err)
+}
+
+func TestTopics_UpdateReplicationsFactorWithoutKafkaClient(t *testing.T) {
+	replicas := map[string]string{
+		"/kafka/v3/clusters/cluster-1/topics/topic-1/partitions/0/replicas": `{"data": [{"broker_id": 1}, {"broker_id": 2}, {"broker_id": 3}]}`,
+		"/kafka/v3/clusters/cluster-1/topics/topic-1/partitions/1/replicas": `{"data": [{"broker_id": 2}, {"broker_id": 3}]}`,
+	}
+	mock := MockHttpClient{}
+	mock.DoRequestFn = func(method string, uri string, reqBody io.Reader) (responseBody []byte, statusCode int, status string, err error) {
+		assert.Equal(t, http.MethodGet, method)
+		switch {
+		case uri == "/kafka/v3/clusters/cluster-1/brokers":
+			return []byte(`{"data": [{"broker_id": 1}, {"broker_id": 2}, {"broker_id": 3}]}`), 200, "200 OK", nil
+		case strings.HasSuffix(uri, "/partition-replicas"):
+			return []byte(`{"data": []}`), 200, "200 OK", nil
+		case uri == "/kafka/v3/clusters/cluster-1/topics/topic-1/partitions":
+			return []byte(`{"data": [{"partition_id": 0}, {"partition_id": 1}]}`), 200, "200 OK", nil
+		}
+		r, ok := replicas[uri]
+		assert.True(t, ok, "Unexpected uri %s", uri)
+		return []byte(r), 200, "200 OK", nil
+	}
+	c := NewClient(&mock, nil, nil)
+
+	// The plan is read through the REST API, the partition 1 already has 2 replicas
+	plan, err := c.PlanReplicationsFactor(Topic{ClusterID: clusterId, Name: "topic-1", ReplicationFactor: 2})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[int32][]int32{0: {1, 2}}, plan.assignment())
+	}
+
+	err = c.UpdateReplicationsFactor(Topic{ClusterID: clusterId, Name: "topic-1", ReplicationFactor: 2})
+	assert.Equal(t, errors.New("UpdateReplicationsFactor requires a kafka client"), err)
+
+	_, err = c.PlanReplicationsFactor(Topic{ClusterID: clusterId, Name: "topic-1", ReplicationFactor: 4})
+	assert.Equal(t, errors.New("not enough brokers"), err)
+}